@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -49,6 +50,14 @@ type Asset struct {
 	Size           int    `json:"size"`
 	Owner          string `json:"owner"`
 	AppraisedValue int    `json:"appraisedValue"`
+	Version        int    `json:"version"` // incremented on every write; see UpdateAsset
+}
+
+// AssetTransferredEvent: payload of the "AssetTransferred" chaincode event.
+type AssetTransferredEvent struct {
+	AssetID  string `json:"assetId"`
+	OldOwner string `json:"oldOwner"`
+	NewOwner string `json:"newOwner"`
 }
 
 // ------------------ SmartContract ------------------
@@ -59,20 +68,80 @@ type SmartContract struct {
 
 // ------------------ Helpers ------------------
 
-// hasRole: check attribute "role" from identity. If attribute absent, permissive for local testing.
-// For production: change to require attribute presence and exact match.
-func (s *SmartContract) hasRole(ctx contractapi.TransactionContextInterface, role string) bool {
-	ci := ctx.GetClientIdentity()
-	val, found, err := ci.GetAttributeValue("role")
-	if err != nil {
-		// Can't read attributes (e.g., CLI cert). Permissive to ease testing.
+// Role attributes in order of precedence: the custom "role" wins over the Fabric CA "hf.Role".
+const (
+	roleAttribute       = "role"
+	fabricRoleAttribute = "hf.Role"
+)
+
+// resolveRole: return the caller's role from the first role attribute present.
+func resolveRole(ctx contractapi.TransactionContextInterface) (string, bool) {
+	for _, attr := range []string{roleAttribute, fabricRoleAttribute} {
+		val, found, err := ctx.GetClientIdentity().GetAttributeValue(attr)
+		if err == nil && found {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// rolesAttribute: comma-separated role list for identities acting in several roles (e.g. a co-op).
+const rolesAttribute = "roles"
+
+// enforceRolesKey: ledger flag; "true" (or unset) denies callers without a role attribute,
+// "false" lets them through for local dev networks where CLI certs carry no attributes.
+const enforceRolesKey = "ENFORCE_ROLES"
+
+// enforceRoles: read the ENFORCE_ROLES flag, defaulting to strict when unset or unreadable.
+func enforceRoles(ctx contractapi.TransactionContextInterface) bool {
+	b, err := ctx.GetStub().GetState(enforceRolesKey)
+	if err != nil || b == nil {
 		return true
 	}
-	if !found {
-		// Not set on identity — permissive for test environment.
+	enabled, err := strconv.ParseBool(string(b))
+	return err != nil || enabled
+}
+
+// SetEnforceRoles: set the ENFORCE_ROLES flag. Only an authority may disable it; while unset, any caller
+// may pin it to strict mode, and once set only an authority (per the current mode) may change it.
+func (s *SmartContract) SetEnforceRoles(ctx contractapi.TransactionContextInterface, enabledStr string) error {
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return fmt.Errorf("invalid enabled value '%s': %v", enabledStr, err)
+	}
+	existing, err := ctx.GetStub().GetState(enforceRolesKey)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", enforceRolesKey, err)
+	}
+	if !s.hasRole(ctx, "authority") {
+		if !enabled {
+			return fmt.Errorf("only authority can disable %s", enforceRolesKey)
+		}
+		if existing != nil {
+			return fmt.Errorf("only authority can change %s", enforceRolesKey)
+		}
+	}
+	return ctx.GetStub().PutState(enforceRolesKey, []byte(strconv.FormatBool(enabled)))
+}
+
+// hasRole: check the caller's role via resolveRole or the "roles" list. A caller with no role attribute
+// is denied unless ENFORCE_ROLES is false (local testing only).
+func (s *SmartContract) hasRole(ctx contractapi.TransactionContextInterface, role string) bool {
+	val, found := resolveRole(ctx)
+	if found && val == role {
 		return true
 	}
-	return val == role
+	roles, rolesFound, err := ctx.GetClientIdentity().GetAttributeValue(rolesAttribute)
+	if err == nil && rolesFound {
+		for _, r := range strings.Split(roles, ",") {
+			if strings.TrimSpace(r) == role {
+				return true
+			}
+		}
+		return false
+	}
+	// Not set on identity or can't read attributes (e.g., CLI cert) — only allowed in permissive mode.
+	return !found && !enforceRoles(ctx)
 }
 
 // isCaller: compare client's ID. For production use a stable attribute instead.
@@ -84,12 +153,38 @@ func (s *SmartContract) isCaller(ctx contractapi.TransactionContextInterface, id
 	return clientID == id
 }
 
+// requireNonEmpty: reject blank IDs, which would produce bare-prefix keys like "CATCH_".
+func requireNonEmpty(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("field %s must not be empty", name)
+	}
+	return nil
+}
+
+// prefixEnd: exclusive range end for every key starting with prefix; a "~" sentinel would
+// miss IDs with bytes above '~', such as non-ASCII characters.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	end[len(end)-1]++
+	return string(end)
+}
+
 // ------------------ Fisher functions ------------------
 
 func (s *SmartContract) RegisterFisher(ctx contractapi.TransactionContextInterface, id, name, govtId string) error {
 	if !s.hasRole(ctx, "authority") {
 		return fmt.Errorf("only authority can register fishers")
 	}
+	if err := requireNonEmpty("id", id); err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState("FISHER_" + id)
+	if err != nil {
+		return fmt.Errorf("failed to read fisher %s: %v", id, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("fisher %s already exists", id)
+	}
 	f := Fisher{ID: id, Name: name, GovtID: govtId, Role: "fisher"}
 	b, err := json.Marshal(f)
 	if err != nil {
@@ -117,10 +212,16 @@ func (s *SmartContract) GetFisher(ctx contractapi.TransactionContextInterface, f
 
 // LogCatch expects weightKg as string (so CLI can pass it). Date should be ISO string.
 func (s *SmartContract) LogCatch(ctx contractapi.TransactionContextInterface, catchId, fisherId, species, weightKgStr, date string) error {
-	// enforce fisher role AND caller identity; permissive if attributes absent (for testing)
+	// enforce fisher role or caller identity; attribute-less callers pass only when ENFORCE_ROLES is false
 	if !s.hasRole(ctx, "fisher") && !s.isCaller(ctx, fisherId) {
 		return fmt.Errorf("only the fisher can log their catch")
 	}
+	if err := requireNonEmpty("catchId", catchId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("fisherId", fisherId); err != nil {
+		return err
+	}
 	weightKg, err := strconv.ParseFloat(weightKgStr, 64)
 	if err != nil {
 		return fmt.Errorf("invalid weightKg: %v", err)
@@ -154,6 +255,12 @@ func (s *SmartContract) CreateBatch(ctx contractapi.TransactionContextInterface,
 	if !s.hasRole(ctx, "processor") {
 		return fmt.Errorf("only processor can create batches")
 	}
+	if err := requireNonEmpty("batchId", batchId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("processorId", processorId); err != nil {
+		return err
+	}
 	batch := Batch{BatchID: batchId, CatchIDs: catchIds, ProcessorID: processorId, Date: date, QRCodeURL: fmt.Sprintf("https://example.org/batch/%s", batchId)}
 	b, err := json.Marshal(batch)
 	if err != nil {
@@ -179,6 +286,15 @@ func (s *SmartContract) PlaceOrder(ctx contractapi.TransactionContextInterface,
 	if !s.hasRole(ctx, "buyer") {
 		return fmt.Errorf("only buyer can place orders")
 	}
+	if err := requireNonEmpty("orderId", orderId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("batchId", batchId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("buyerId", buyerId); err != nil {
+		return err
+	}
 	o := Order{OrderID: orderId, BatchID: batchId, BuyerID: buyerId, Status: "placed", Date: date}
 	b, err := json.Marshal(o)
 	if err != nil {
@@ -193,7 +309,7 @@ func (s *SmartContract) GenerateReport(ctx contractapi.TransactionContextInterfa
 	if !s.hasRole(ctx, "authority") {
 		return "", fmt.Errorf("only authority can generate reports")
 	}
-	iter, err := ctx.GetStub().GetStateByRange("CATCH_", "CATCH_~")
+	iter, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
 	if err != nil {
 		return "", err
 	}
@@ -231,11 +347,97 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("invalid appraisedValue: %v", err)
 	}
 	a := Asset{ID: id, Color: color, Size: size, Owner: owner, AppraisedValue: appVal}
-	b, _ := json.Marshal(a)
-	return ctx.GetStub().PutState("ASSET_"+id, b)
+	if err := validateAsset(&a); err != nil {
+		return err
+	}
+	return putNewAsset(ctx, &a)
+}
+
+// CreateAssets: create every asset in a JSON array, validating all of them before writing any.
+func (s *SmartContract) CreateAssets(ctx contractapi.TransactionContextInterface, assetsJSON string) error {
+	var assets []Asset
+	if err := json.Unmarshal([]byte(assetsJSON), &assets); err != nil {
+		return fmt.Errorf("failed to unmarshal assets: %v", err)
+	}
+	if len(assets) == 0 {
+		return fmt.Errorf("no assets to create")
+	}
+	seen := make(map[string]bool)
+	for i := range assets {
+		if err := validateAsset(&assets[i]); err != nil {
+			return fmt.Errorf("asset %d (%s) invalid: %v", i, assets[i].ID, err)
+		}
+		if seen[assets[i].ID] {
+			return fmt.Errorf("asset %d (%s) invalid: listed more than once", i, assets[i].ID)
+		}
+		seen[assets[i].ID] = true
+		exists, err := assetExists(ctx, assets[i].ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("asset %d (%s) invalid: already exists", i, assets[i].ID)
+		}
+	}
+	for i := range assets {
+		if err := putNewAsset(ctx, &assets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAsset: require an ID and owner, and non-negative size and appraised value.
+func validateAsset(a *Asset) error {
+	if err := requireNonEmpty("id", a.ID); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("owner", a.Owner); err != nil {
+		return err
+	}
+	if a.Size < 0 {
+		return fmt.Errorf("size must not be negative")
+	}
+	if a.AppraisedValue < 0 {
+		return fmt.Errorf("appraisedValue must not be negative")
+	}
+	return nil
+}
+
+// assetExists: report whether a live asset is stored under id.
+func assetExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	b, err := ctx.GetStub().GetState("ASSET_" + id)
+	if err != nil {
+		return false, err
+	}
+	return b != nil, nil
+}
+
+// putNewAsset: write a created asset at version 1, clearing any tombstone left by an earlier delete.
+// An ID that is already in use is rejected rather than overwritten.
+func putNewAsset(ctx contractapi.TransactionContextInterface, a *Asset) error {
+	exists, err := assetExists(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("asset %s already exists", a.ID)
+	}
+	a.Version = 1
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(assetTombstonePrefix + a.ID); err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState("ASSET_"+a.ID, b)
 }
 
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	if err := requireNonEmpty("id", id); err != nil {
+		return nil, err
+	}
 	b, err := ctx.GetStub().GetState("ASSET_" + id)
 	if err != nil {
 		return nil, err
@@ -250,7 +452,38 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, i
 	return &a, nil
 }
 
+// QueryAssetsByOwner: range-scan ASSET_ keys and return the assets held by owner.
+func (s *SmartContract) QueryAssetsByOwner(ctx contractapi.TransactionContextInterface, owner string) ([]*Asset, error) {
+	iter, err := ctx.GetStub().GetStateByRange("ASSET_", prefixEnd("ASSET_"))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	out := []*Asset{}
+	for iter.HasNext() {
+		r, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		var a Asset
+		if err := json.Unmarshal(r.Value, &a); err != nil {
+			return nil, err
+		}
+		if a.Owner == owner {
+			out = append(out, &a)
+		}
+	}
+	return out, nil
+}
+
+// TransferAsset: hand an asset to newOwner; only the current owner (by client ID) or an authority may.
 func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, assetKey, newOwner string) error {
+	if err := requireNonEmpty("assetKey", assetKey); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("newOwner", newOwner); err != nil {
+		return err
+	}
 	b, err := ctx.GetStub().GetState("ASSET_" + assetKey)
 	if err != nil {
 		return err
@@ -262,12 +495,29 @@ func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterfac
 	if err := json.Unmarshal(b, &a); err != nil {
 		return err
 	}
+	if !s.isCaller(ctx, a.Owner) && !s.hasRole(ctx, "authority") {
+		return fmt.Errorf("only the owner can transfer")
+	}
+	oldOwner := a.Owner
 	a.Owner = newOwner
+	a.Version++
 	updated, _ := json.Marshal(a)
-	return ctx.GetStub().PutState("ASSET_"+assetKey, updated)
+	if err := ctx.GetStub().PutState("ASSET_"+assetKey, updated); err != nil {
+		return err
+	}
+	event, err := json.Marshal(AssetTransferredEvent{AssetID: assetKey, OldOwner: oldOwner, NewOwner: newOwner})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent("AssetTransferred", event)
 }
 
-func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetKey, color, sizeStr, appraisedValueStr string) error {
+// UpdateAsset: overwrite an asset's mutable fields. expectedVersionStr is optional; when set the update
+// is rejected unless it matches the stored Version, so concurrent editors can't clobber each other.
+func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetKey, color, sizeStr, appraisedValueStr, expectedVersionStr string) error {
+	if err := requireNonEmpty("assetKey", assetKey); err != nil {
+		return err
+	}
 	b, err := ctx.GetStub().GetState("ASSET_" + assetKey)
 	if err != nil {
 		return err
@@ -279,6 +529,15 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	if err := json.Unmarshal(b, &a); err != nil {
 		return err
 	}
+	if expectedVersionStr != "" {
+		expectedVersion, err := strconv.Atoi(expectedVersionStr)
+		if err != nil {
+			return fmt.Errorf("invalid expectedVersion: %v", err)
+		}
+		if expectedVersion != a.Version {
+			return fmt.Errorf("version conflict: asset %s is at version %d, not %d", assetKey, a.Version, expectedVersion)
+		}
+	}
 	size, err := strconv.Atoi(sizeStr)
 	if err != nil {
 		return err
@@ -290,19 +549,37 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 	a.Color = color
 	a.Size = size
 	a.AppraisedValue = appVal
+	a.Version++
 	updated, _ := json.Marshal(a)
 	return ctx.GetStub().PutState("ASSET_"+assetKey, updated)
 }
 
+// assetTombstonePrefix: marks deleted assets so a retried delete can tell "already deleted" from "never existed".
+const assetTombstonePrefix = "ASSETDEL_"
+
+// DeleteAsset: remove an asset and leave a tombstone holding the deleting transaction's ID.
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, assetKey string) error {
+	if err := requireNonEmpty("assetKey", assetKey); err != nil {
+		return err
+	}
 	b, err := ctx.GetStub().GetState("ASSET_" + assetKey)
 	if err != nil {
 		return err
 	}
 	if b == nil {
-		return fmt.Errorf("asset %s not found", assetKey)
+		tombstone, err := ctx.GetStub().GetState(assetTombstonePrefix + assetKey)
+		if err != nil {
+			return err
+		}
+		if tombstone != nil {
+			return fmt.Errorf("asset %s already deleted", assetKey)
+		}
+		return fmt.Errorf("asset %s never existed", assetKey)
+	}
+	if err := ctx.GetStub().DelState("ASSET_" + assetKey); err != nil {
+		return err
 	}
-	return ctx.GetStub().DelState("ASSET_" + assetKey)
+	return ctx.GetStub().PutState(assetTombstonePrefix+assetKey, []byte(ctx.GetStub().GetTxID()))
 }
 
 // ------------------ Main ------------------
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// mockIdentity is a settable cid.ClientIdentity for driving role and caller checks.
+type mockIdentity struct {
+	id    string
+	mspID string
+	attrs map[string]string
+}
+
+func (m *mockIdentity) GetID() (string, error)    { return m.id, nil }
+func (m *mockIdentity) GetMSPID() (string, error) { return m.mspID, nil }
+
+func (m *mockIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := m.attrs[name]
+	return value, found, nil
+}
+
+func (m *mockIdentity) AssertAttributeValue(name, value string) error {
+	if m.attrs[name] != value {
+		return fmt.Errorf("attribute %s is not %s", name, value)
+	}
+	return nil
+}
+
+func (m *mockIdentity) GetX509Certificate() (*x509.Certificate, error) { return nil, nil }
+
+func (m *mockIdentity) SetAttributeValue(name, value string) { m.attrs[name] = value }
+func (m *mockIdentity) SetID(id string)                      { m.id = id }
+
+// newContext: a transaction context on stub whose caller has no attributes.
+func newContext(stub *shimtest.MockStub) (*contractapi.TransactionContext, *mockIdentity) {
+	identity := &mockIdentity{attrs: map[string]string{}}
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(identity)
+	return ctx, identity
+}
+
+func setupStub(t *testing.T) (*shimtest.MockStub, *contractapi.TransactionContext, *mockIdentity) {
+	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	if err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+	stub := shimtest.NewMockStub("bigdatacc", chaincode)
+	stub.MockTransactionStart("tx1")
+	ctx, identity := newContext(stub)
+	return stub, ctx, identity
+}
+
+func TestEnforceRoles(t *testing.T) {
+	stub, ctx, identity := setupStub(t)
+	sc := &SmartContract{}
+
+	// Strict by default: a caller without role attributes is denied
+	err := sc.CreateBatch(ctx, "B001", []string{"C001"}, "P001", "2025-08-09")
+	if err == nil || err.Error() != "only processor can create batches" {
+		t.Errorf("CreateBatch should be denied in strict mode, got %v", err)
+	}
+
+	// A caller without a role can't bootstrap the flag into permissive mode
+	err = sc.SetEnforceRoles(ctx, "false")
+	if err == nil || err.Error() != "only authority can disable ENFORCE_ROLES" {
+		t.Errorf("SetEnforceRoles should reject a role-less first call with false, got %v", err)
+	}
+	if b, _ := stub.GetState(enforceRolesKey); b != nil {
+		t.Errorf("ENFORCE_ROLES should stay unset, got %s", b)
+	}
+
+	// ...but may pin it to strict mode, after which only an authority can change it
+	if err := sc.SetEnforceRoles(ctx, "true"); err != nil {
+		t.Fatalf("SetEnforceRoles failed: %v", err)
+	}
+	if err := sc.SetEnforceRoles(ctx, "true"); err == nil || err.Error() != "only authority can change ENFORCE_ROLES" {
+		t.Errorf("SetEnforceRoles should require authority once set, got %v", err)
+	}
+
+	// A role attribute that doesn't match is denied
+	identity.SetAttributeValue("role", "buyer")
+	err = sc.CreateBatch(ctx, "B002", []string{"C001"}, "P001", "2025-08-09")
+	if err == nil || err.Error() != "only processor can create batches" {
+		t.Errorf("CreateBatch should be denied for a buyer, got %v", err)
+	}
+	if err := sc.SetEnforceRoles(ctx, "false"); err == nil || err.Error() != "only authority can disable ENFORCE_ROLES" {
+		t.Errorf("SetEnforceRoles should require authority to disable, got %v", err)
+	}
+
+	identity.SetAttributeValue("role", "authority")
+	if err := sc.SetEnforceRoles(ctx, "false"); err != nil {
+		t.Fatalf("SetEnforceRoles failed: %v", err)
+	}
+	if err := sc.SetEnforceRoles(ctx, "maybe"); err == nil {
+		t.Error("SetEnforceRoles should reject a non-boolean value")
+	}
+
+	// Permissive mode lets a role-less caller through
+	noRole, _ := newContext(stub)
+	if err := sc.CreateBatch(noRole, "B001", []string{"C001"}, "P001", "2025-08-09"); err != nil {
+		t.Errorf("CreateBatch should be allowed in permissive mode, got %v", err)
+	}
+}
+
+func TestDeleteAssetTombstone(t *testing.T) {
+	_, ctx, _ := setupStub(t)
+	sc := &SmartContract{}
+	if err := sc.CreateAsset(ctx, "A001", "blue", "5", "Tom", "300"); err != nil {
+		t.Fatalf("CreateAsset failed: %v", err)
+	}
+
+	// First delete
+	if err := sc.DeleteAsset(ctx, "A001"); err != nil {
+		t.Fatalf("DeleteAsset failed: %v", err)
+	}
+	if _, err := sc.ReadAsset(ctx, "A001"); err == nil {
+		t.Error("asset A001 should be gone after delete")
+	}
+
+	// Retried delete
+	err := sc.DeleteAsset(ctx, "A001")
+	if err == nil || err.Error() != "asset A001 already deleted" {
+		t.Errorf("expected already deleted error, got %v", err)
+	}
+
+	// Never existed
+	err = sc.DeleteAsset(ctx, "A999")
+	if err == nil || err.Error() != "asset A999 never existed" {
+		t.Errorf("expected never existed error, got %v", err)
+	}
+
+	// Recreating clears the tombstone
+	if err := sc.CreateAsset(ctx, "A001", "red", "5", "Tom", "300"); err != nil {
+		t.Fatalf("CreateAsset failed: %v", err)
+	}
+	if err := sc.DeleteAsset(ctx, "A001"); err != nil {
+		t.Errorf("DeleteAsset of recreated asset failed: %v", err)
+	}
+}
+
+func TestCreateAssets(t *testing.T) {
+	_, ctx, _ := setupStub(t)
+	sc := &SmartContract{}
+
+	valid := `[{"id":"A001","color":"blue","size":5,"owner":"Tom","appraisedValue":300},
+		{"id":"A002","color":"red","size":0,"owner":"Ann","appraisedValue":0}]`
+	if err := sc.CreateAssets(ctx, valid); err != nil {
+		t.Fatalf("CreateAssets failed: %v", err)
+	}
+	for _, id := range []string{"A001", "A002"} {
+		if _, err := sc.ReadAsset(ctx, id); err != nil {
+			t.Errorf("asset %s should exist: %v", id, err)
+		}
+	}
+
+	// One invalid entry rejects the whole batch
+	invalid := `[{"id":"A003","color":"green","size":3,"owner":"Tom","appraisedValue":100},
+		{"id":"A004","color":"black","size":-1,"owner":"Ann","appraisedValue":100}]`
+	err := sc.CreateAssets(ctx, invalid)
+	if err == nil || err.Error() != "asset 1 (A004) invalid: size must not be negative" {
+		t.Errorf("expected invalid size error, got %v", err)
+	}
+	if _, err := sc.ReadAsset(ctx, "A003"); err == nil {
+		t.Error("asset A003 should not be written when the batch is invalid")
+	}
+
+	// An existing ID rejects the whole batch before anything is written
+	clash := `[{"id":"A005","color":"green","size":3,"owner":"Tom","appraisedValue":100},
+		{"id":"A001","color":"black","size":1,"owner":"Eve","appraisedValue":1}]`
+	err = sc.CreateAssets(ctx, clash)
+	if err == nil || err.Error() != "asset 1 (A001) invalid: already exists" {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+	if _, err := sc.ReadAsset(ctx, "A005"); err == nil {
+		t.Error("asset A005 should not be written when the batch clashes")
+	}
+	if a, _ := sc.ReadAsset(ctx, "A001"); a == nil || a.Owner != "Tom" {
+		t.Errorf("asset A001 should be unchanged, got %+v", a)
+	}
+
+	// CreateAsset doesn't overwrite either
+	err = sc.CreateAsset(ctx, "A002", "white", "1", "Eve", "1")
+	if err == nil || err.Error() != "asset A002 already exists" {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+}
+
+func TestQueryAssetsByOwner(t *testing.T) {
+	_, ctx, _ := setupStub(t)
+	sc := &SmartContract{}
+	for _, a := range [][]string{
+		{"A001", "blue", "5", "Tom", "300"},
+		{"A002", "red", "3", "Ann", "200"},
+		{"A003", "green", "8", "Tom", "500"},
+		{"Ø004", "black", "2", "Tom", "100"},
+	} {
+		if err := sc.CreateAsset(ctx, a[0], a[1], a[2], a[3], a[4]); err != nil {
+			t.Fatalf("CreateAsset %s failed: %v", a[0], err)
+		}
+	}
+
+	assets, err := sc.QueryAssetsByOwner(ctx, "Tom")
+	if err != nil {
+		t.Fatalf("QueryAssetsByOwner failed: %v", err)
+	}
+	if len(assets) != 3 {
+		t.Fatalf("expected 3 assets for Tom, got %d", len(assets))
+	}
+	for _, a := range assets {
+		if a.Owner != "Tom" || (a.ID != "A001" && a.ID != "A003" && a.ID != "Ø004") {
+			t.Errorf("unexpected asset %+v", a)
+		}
+	}
+
+	assets, err = sc.QueryAssetsByOwner(ctx, "Ann")
+	if err != nil || len(assets) != 1 || assets[0].ID != "A002" {
+		t.Errorf("expected A002 for Ann, got %v, %v", assets, err)
+	}
+
+	assets, err = sc.QueryAssetsByOwner(ctx, "Nobody")
+	if err != nil || assets == nil || len(assets) != 0 {
+		t.Errorf("expected an empty slice for an unknown owner, got %v, %v", assets, err)
+	}
+}
+
+func TestTransferAsset(t *testing.T) {
+	stub, ctx, identity := setupStub(t)
+	sc := &SmartContract{}
+	if err := sc.CreateAsset(ctx, "A001", "blue", "5", "Tom", "300"); err != nil {
+		t.Fatalf("CreateAsset failed: %v", err)
+	}
+
+	// Non-owner is rejected
+	identity.SetAttributeValue("role", "buyer")
+	identity.SetID("Mallory")
+	err := sc.TransferAsset(ctx, "A001", "Mallory")
+	if err == nil || err.Error() != "only the owner can transfer" {
+		t.Errorf("expected owner check to fail, got %v", err)
+	}
+
+	// Owner transfers and the event fires
+	identity.SetID("Tom")
+	if err := sc.TransferAsset(ctx, "A001", "Ann"); err != nil {
+		t.Fatalf("TransferAsset failed: %v", err)
+	}
+	a, _ := sc.ReadAsset(ctx, "A001")
+	if a.Owner != "Ann" {
+		t.Errorf("expected owner Ann, got %s", a.Owner)
+	}
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		var payload AssetTransferredEvent
+		json.Unmarshal(event.Payload, &payload)
+		if event.EventName != "AssetTransferred" || payload.AssetID != "A001" || payload.OldOwner != "Tom" || payload.NewOwner != "Ann" {
+			t.Errorf("unexpected event %s %+v", event.EventName, payload)
+		}
+	default:
+		t.Error("expected AssetTransferred event")
+	}
+
+	// The previous owner no longer can
+	err = sc.TransferAsset(ctx, "A001", "Tom")
+	if err == nil || err.Error() != "only the owner can transfer" {
+		t.Errorf("expected previous owner to be rejected, got %v", err)
+	}
+
+	// An authority may transfer any asset
+	identity.SetAttributeValue("role", "authority")
+	if err := sc.TransferAsset(ctx, "A001", "Tom"); err != nil {
+		t.Errorf("TransferAsset by authority failed: %v", err)
+	}
+}
+
+func TestUpdateAssetVersion(t *testing.T) {
+	_, ctx, _ := setupStub(t)
+	sc := &SmartContract{}
+	if err := sc.CreateAsset(ctx, "A001", "blue", "5", "Tom", "300"); err != nil {
+		t.Fatalf("CreateAsset failed: %v", err)
+	}
+	a, _ := sc.ReadAsset(ctx, "A001")
+	if a.Version != 1 {
+		t.Errorf("expected a new asset at version 1, got %d", a.Version)
+	}
+
+	// Matching version succeeds and bumps the version
+	if err := sc.UpdateAsset(ctx, "A001", "red", "6", "350", "1"); err != nil {
+		t.Fatalf("UpdateAsset failed: %v", err)
+	}
+	a, _ = sc.ReadAsset(ctx, "A001")
+	if a.Version != 2 || a.Color != "red" || a.Size != 6 || a.AppraisedValue != 350 {
+		t.Errorf("unexpected asset after update %+v", a)
+	}
+
+	// Stale version is rejected and nothing changes
+	err := sc.UpdateAsset(ctx, "A001", "green", "7", "400", "1")
+	if err == nil || err.Error() != "version conflict: asset A001 is at version 2, not 1" {
+		t.Errorf("expected version conflict, got %v", err)
+	}
+	a, _ = sc.ReadAsset(ctx, "A001")
+	if a.Version != 2 || a.Color != "red" {
+		t.Errorf("stale update should not be applied, got %+v", a)
+	}
+
+	// Omitting the version skips the check
+	if err := sc.UpdateAsset(ctx, "A001", "green", "7", "400", ""); err != nil {
+		t.Fatalf("UpdateAsset without version failed: %v", err)
+	}
+	a, _ = sc.ReadAsset(ctx, "A001")
+	if a.Version != 3 {
+		t.Errorf("expected version 3, got %d", a.Version)
+	}
+}
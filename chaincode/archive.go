@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ArchiveCatch hides a catch from default queries while keeping it on the ledger for retention
+func (s *SmartContract) ArchiveCatch(ctx contractapi.TransactionContextInterface, catchId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can archive catches")
+	}
+
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+	if catch.Archived {
+		return nil
+	}
+	catch.Archived = true
+	if err := adjustSpeciesCount(ctx, catch.Species, -1); err != nil {
+		return err
+	}
+
+	catchBytes, err := json.Marshal(catch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal catch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CATCH_"+catchId, catchBytes)
+}
+
+// ArchiveBatch hides a batch from default queries while keeping it on the ledger for retention
+func (s *SmartContract) ArchiveBatch(ctx contractapi.TransactionContextInterface, batchId string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can archive batches")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	batch.Archived = true
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// ArchiveOrder hides an order from default queries while keeping it on the ledger for retention
+func (s *SmartContract) ArchiveOrder(ctx contractapi.TransactionContextInterface, orderId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can archive orders")
+	}
+
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	order.Archived = true
+
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+}
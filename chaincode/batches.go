@@ -0,0 +1,846 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BatchWithCatches is a batch with its catch records resolved inline
+type BatchWithCatches struct {
+	Batch   *Batch   `json:"batch"`
+	Catches []*Catch `json:"catches"`
+	Missing []string `json:"missing"`
+}
+
+// GetBatchWithCatches returns a batch and its full catch records as one JSON document.
+// Catch IDs that can't be resolved are listed under "missing" rather than failing the call.
+func (s *SmartContract) GetBatchWithCatches(ctx contractapi.TransactionContextInterface, batchId string) (string, error) {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return "", err
+	}
+
+	expanded := BatchWithCatches{Batch: batch, Catches: []*Catch{}, Missing: []string{}}
+	for _, catchId := range batch.CatchIDs {
+		catch, err := s.GetCatch(ctx, catchId)
+		if err != nil {
+			expanded.Missing = append(expanded.Missing, catchId)
+			continue
+		}
+		expanded.Catches = append(expanded.Catches, catch)
+	}
+
+	expandedBytes, err := json.Marshal(expanded)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return string(expandedBytes), nil
+}
+
+// AttachCertificate records a certificate reference, such as a health or catch certificate, on a batch
+func (s *SmartContract) AttachCertificate(ctx contractapi.TransactionContextInterface, batchId, certType, reference, issuedBy, issuedDate string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can attach certificates")
+	}
+
+	if err := requireNonEmpty("type", certType); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("reference", reference); err != nil {
+		return err
+	}
+	if err := validateDate(issuedDate); err != nil {
+		return err
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	batch.Certificates = append(batch.Certificates, Certificate{
+		Type:       certType,
+		Reference:  reference,
+		IssuedBy:   issuedBy,
+		IssuedDate: issuedDate,
+	})
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// GetBatchCertificates returns the certificates attached to a batch
+func (s *SmartContract) GetBatchCertificates(ctx contractapi.TransactionContextInterface, batchId string) ([]Certificate, error) {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Certificates == nil {
+		return []Certificate{}, nil
+	}
+	return batch.Certificates, nil
+}
+
+// MarkBatchProcessed records when processing of a received batch finished
+func (s *SmartContract) MarkBatchProcessed(ctx contractapi.TransactionContextInterface, batchId string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can mark batches processed")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if batch.ProcessedAt != "" {
+		return fmt.Errorf("batch %s already processed", batchId)
+	}
+
+	batch.ProcessedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// BatchProcessingTime holds the seconds a batch spent between lifecycle stages; stages not yet reached are zero
+type BatchProcessingTime struct {
+	BatchID                    string  `json:"batchId"`
+	ReceivedToProcessedSeconds float64 `json:"receivedToProcessedSeconds"`
+	ProcessedToShippedSeconds  float64 `json:"processedToShippedSeconds"`
+	ReceivedToShippedSeconds   float64 `json:"receivedToShippedSeconds"`
+}
+
+// GetBatchProcessingTime returns the durations between a batch being received, processed and shipped
+func (s *SmartContract) GetBatchProcessingTime(ctx contractapi.TransactionContextInterface, batchId string) (*BatchProcessingTime, error) {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Batches created before ReceivedAt existed were received when recorded
+	receivedAt := batch.ReceivedAt
+	if receivedAt == "" {
+		receivedAt = batch.RecordedAt
+	}
+
+	timing := &BatchProcessingTime{BatchID: batchId}
+	if timing.ReceivedToProcessedSeconds, err = secondsBetween(receivedAt, batch.ProcessedAt); err != nil {
+		return nil, err
+	}
+	if timing.ProcessedToShippedSeconds, err = secondsBetween(batch.ProcessedAt, batch.ShippedAt); err != nil {
+		return nil, err
+	}
+	if timing.ReceivedToShippedSeconds, err = secondsBetween(receivedAt, batch.ShippedAt); err != nil {
+		return nil, err
+	}
+	return timing, nil
+}
+
+// secondsBetween returns the seconds from one RFC3339 timestamp to another, or zero if either is unset
+func secondsBetween(from, to string) (float64, error) {
+	if from == "" || to == "" {
+		return 0, nil
+	}
+	fromTime, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %v", from, err)
+	}
+	toTime, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %v", to, err)
+	}
+	return toTime.Sub(fromTime).Seconds(), nil
+}
+
+// BatchWeightCheck compares a batch's declared weight with the sum of its catches
+type BatchWeightCheck struct {
+	BatchID          string  `json:"batchId"`
+	DeclaredWeightKg float64 `json:"declaredWeightKg"`
+	CatchWeightKg    float64 `json:"catchWeightKg"`
+	ToleranceKg      float64 `json:"toleranceKg"`
+	WithinTolerance  bool    `json:"withinTolerance"`
+}
+
+// ValidateBatchWeight reports whether a batch's declared weight is within toleranceStr kg of its catches' total
+func (s *SmartContract) ValidateBatchWeight(ctx contractapi.TransactionContextInterface, batchId, toleranceStr string) (*BatchWeightCheck, error) {
+	tolerance, err := strconv.ParseFloat(toleranceStr, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tolerance value '%s': %v", toleranceStr, err)
+	}
+	if tolerance < 0 {
+		return nil, fmt.Errorf("tolerance must not be negative")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return nil, err
+	}
+	if batch.DeclaredWeightKg == 0 {
+		return nil, fmt.Errorf("batch %s has no declared weight", batchId)
+	}
+
+	totalKg, err := s.catchWeightKg(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchWeightCheck{
+		BatchID:          batchId,
+		DeclaredWeightKg: batch.DeclaredWeightKg,
+		CatchWeightKg:    totalKg,
+		ToleranceKg:      tolerance,
+		WithinTolerance:  math.Abs(batch.DeclaredWeightKg-totalKg) <= tolerance,
+	}, nil
+}
+
+// HoldBatch places a batch on inspection hold; orders against it cannot be confirmed or shipped until released
+func (s *SmartContract) HoldBatch(ctx contractapi.TransactionContextInterface, batchId, reason string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can hold batches")
+	}
+
+	if err := requireNonEmpty("reason", reason); err != nil {
+		return err
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if batch.OnHold {
+		return fmt.Errorf("batch %s is already on hold", batchId)
+	}
+	batch.OnHold = true
+	batch.HoldReason = reason
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// ReleaseBatch lifts an inspection hold from a batch
+func (s *SmartContract) ReleaseBatch(ctx contractapi.TransactionContextInterface, batchId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can release batches")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if !batch.OnHold {
+		return fmt.Errorf("batch %s is not on hold", batchId)
+	}
+	batch.OnHold = false
+	batch.HoldReason = ""
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// checkNotHeld rejects acting on an order while any of its batches is on hold
+func checkNotHeld(ctx contractapi.TransactionContextInterface, order *Order) error {
+	for _, batchId := range order.BatchIDs {
+		batchBytes, err := ctx.GetStub().GetState("BATCH_" + batchId)
+		if err != nil {
+			return fmt.Errorf("failed to read batch %s: %v", batchId, err)
+		}
+		if batchBytes == nil {
+			continue
+		}
+		var batch Batch
+		if err := json.Unmarshal(batchBytes, &batch); err != nil {
+			return fmt.Errorf("failed to unmarshal batch data: %v", err)
+		}
+		if batch.OnHold {
+			return fmt.Errorf("batch %s is on hold: %s", batchId, batch.HoldReason)
+		}
+	}
+	return nil
+}
+
+// catchWeightKg sums the weights of a batch's catches
+func (s *SmartContract) catchWeightKg(ctx contractapi.TransactionContextInterface, batch *Batch) (float64, error) {
+	var totalKg float64
+	for _, catchId := range batch.CatchIDs {
+		catch, err := s.GetCatch(ctx, catchId)
+		if err != nil {
+			return 0, err
+		}
+		totalKg += catch.WeightKg
+	}
+	return roundWeightKg(totalKg), nil
+}
+
+// batchWeightKg returns a batch's declared weight, falling back to the total of its catches
+func (s *SmartContract) batchWeightKg(ctx contractapi.TransactionContextInterface, batchId string) (float64, error) {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return 0, err
+	}
+	if batch.DeclaredWeightKg > 0 {
+		return batch.DeclaredWeightKg, nil
+	}
+	return s.catchWeightKg(ctx, batch)
+}
+
+// GetAllBatchesByProcessor returns every batch created by the given processor; archived batches
+// are only included when includeArchived is set
+func (s *SmartContract) GetAllBatchesByProcessor(ctx contractapi.TransactionContextInterface, processorId string, includeArchived bool) ([]*Batch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("BATCH_", prefixEnd("BATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	batches := []*Batch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var batch Batch
+		err = json.Unmarshal(queryResponse.Value, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal batch data: %v", err)
+		}
+
+		if batch.Archived && !includeArchived {
+			continue
+		}
+		if batch.ProcessorID == processorId {
+			batches = append(batches, &batch)
+		}
+	}
+
+	return batches, nil
+}
+
+// SplitBatch moves the given catches out of a batch into a new child batch linked to its parent.
+// A child split off a held batch inherits the hold, and a declared weight is shared between
+// parent and child in proportion to their catches' weight.
+func (s *SmartContract) SplitBatch(ctx contractapi.TransactionContextInterface, batchId string, newBatchId string, catchIds []string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can split batches")
+	}
+	if len(catchIds) == 0 {
+		return fmt.Errorf("batch must contain at least one catch")
+	}
+
+	parent, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.BatchExists(ctx, newBatchId)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("batch %s already exists", newBatchId)
+	}
+
+	moving := map[string]bool{}
+	for _, catchId := range catchIds {
+		if moving[catchId] {
+			return fmt.Errorf("catch %s listed more than once", catchId)
+		}
+		moving[catchId] = true
+	}
+	remaining := []string{}
+	for _, catchId := range parent.CatchIDs {
+		if moving[catchId] {
+			delete(moving, catchId)
+			continue
+		}
+		remaining = append(remaining, catchId)
+	}
+	for _, catchId := range catchIds {
+		if moving[catchId] {
+			return fmt.Errorf("catch %s is not in batch %s", catchId, batchId)
+		}
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("split would leave batch %s empty", batchId)
+	}
+
+	recordedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	qrCodeURL, err := batchQRCodeURL(ctx, newBatchId)
+	if err != nil {
+		return err
+	}
+
+	var childDeclaredKg float64
+	if parent.DeclaredWeightKg > 0 {
+		totalKg, err := s.catchWeightKg(ctx, parent)
+		if err != nil {
+			return err
+		}
+		movingKg, err := s.catchWeightKg(ctx, &Batch{CatchIDs: catchIds})
+		if err != nil {
+			return err
+		}
+		if totalKg > 0 {
+			childDeclaredKg = roundWeightKg(parent.DeclaredWeightKg * movingKg / totalKg)
+			parent.DeclaredWeightKg = roundWeightKg(parent.DeclaredWeightKg - childDeclaredKg)
+		} else {
+			parent.DeclaredWeightKg = 0
+		}
+	}
+
+	parent.CatchIDs = remaining
+	child := Batch{
+		BatchID:          newBatchId,
+		CatchIDs:         catchIds,
+		ProcessorID:      parent.ProcessorID,
+		Date:             parent.Date,
+		QRCodeURL:        qrCodeURL,
+		ParentBatchID:    batchId,
+		BestBefore:       parent.BestBefore,
+		DeclaredWeightKg: childDeclaredKg,
+		OnHold:           parent.OnHold,
+		HoldReason:       parent.HoldReason,
+		RecordedAt:       recordedAt,
+	}
+	if err := assignLotNumber(ctx, &child); err != nil {
+		return err
+	}
+
+	for _, batch := range []*Batch{parent, &child} {
+		batchBytes, err := json.Marshal(batch)
+		if err != nil {
+			return fmt.Errorf("failed to marshal batch data: %v", err)
+		}
+		if err := ctx.GetStub().PutState("BATCH_"+batch.BatchID, batchBytes); err != nil {
+			return fmt.Errorf("failed to put batch %s: %v", batch.BatchID, err)
+		}
+	}
+	for _, catchId := range catchIds {
+		if err := moveCatchBatch(ctx, catchId, batchId, newBatchId); err != nil {
+			return err
+		}
+	}
+
+	return emitEvent(ctx, EventBatchCreated, newBatchId)
+}
+
+// MergeBatches folds the catches of the source batches into the target and deletes the sources.
+// All batches must belong to the same processor, and no source may be on hold. The target's
+// declared weight becomes the sum of every batch's declared weight; it is cleared if any batch
+// declared none or a catch appears in more than one of them.
+func (s *SmartContract) MergeBatches(ctx contractapi.TransactionContextInterface, targetBatchId string, sourceBatchIds []string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can merge batches")
+	}
+	if len(sourceBatchIds) == 0 {
+		return fmt.Errorf("at least one source batch is required")
+	}
+
+	target, err := s.GetBatch(ctx, targetBatchId)
+	if err != nil {
+		return err
+	}
+
+	included := map[string]bool{}
+	for _, catchId := range target.CatchIDs {
+		included[catchId] = true
+	}
+	declaredKg := target.DeclaredWeightKg
+	sumDeclared := declaredKg > 0
+
+	merged := map[string]bool{}
+	for _, sourceId := range sourceBatchIds {
+		if sourceId == targetBatchId || merged[sourceId] {
+			return fmt.Errorf("batch %s listed more than once", sourceId)
+		}
+		merged[sourceId] = true
+
+		source, err := s.GetBatch(ctx, sourceId)
+		if err != nil {
+			return err
+		}
+		if source.ProcessorID != target.ProcessorID {
+			return fmt.Errorf("batch %s belongs to processor %s, not %s", sourceId, source.ProcessorID, target.ProcessorID)
+		}
+		if source.OnHold {
+			return fmt.Errorf("batch %s is on hold: %s", sourceId, source.HoldReason)
+		}
+
+		referenced, err := batchHasOrders(ctx, sourceId)
+		if err != nil {
+			return err
+		}
+		if referenced {
+			return fmt.Errorf("batch %s is referenced by an order", sourceId)
+		}
+
+		for _, catchId := range source.CatchIDs {
+			if included[catchId] {
+				sumDeclared = false
+				continue
+			}
+			included[catchId] = true
+			target.CatchIDs = append(target.CatchIDs, catchId)
+		}
+		if source.DeclaredWeightKg > 0 {
+			declaredKg += source.DeclaredWeightKg
+		} else {
+			sumDeclared = false
+		}
+		target.MergedFrom = append(target.MergedFrom, sourceId)
+		// The merged batch is only as fresh as its most perishable source
+		if source.BestBefore != "" && (target.BestBefore == "" || source.BestBefore < target.BestBefore) {
+			target.BestBefore = source.BestBefore
+		}
+	}
+	if sumDeclared {
+		target.DeclaredWeightKg = roundWeightKg(declaredKg)
+	} else {
+		target.DeclaredWeightKg = 0
+	}
+
+	for _, sourceId := range sourceBatchIds {
+		source, err := s.GetBatch(ctx, sourceId)
+		if err != nil {
+			return err
+		}
+		for _, catchId := range source.CatchIDs {
+			if err := moveCatchBatch(ctx, catchId, sourceId, targetBatchId); err != nil {
+				return err
+			}
+		}
+		if err := unindexLotNumber(ctx, source); err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState("BATCH_" + sourceId); err != nil {
+			return fmt.Errorf("failed to delete batch %s: %v", sourceId, err)
+		}
+	}
+
+	targetBytes, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("BATCH_"+targetBatchId, targetBytes)
+}
+
+// DeleteBatch removes a batch record; refused while any order still references it
+func (s *SmartContract) DeleteBatch(ctx contractapi.TransactionContextInterface, batchId string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can delete batches")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+
+	referenced, err := batchHasOrders(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if referenced {
+		return fmt.Errorf("batch %s is referenced by an order", batchId)
+	}
+
+	for _, catchId := range batch.CatchIDs {
+		if err := moveCatchBatch(ctx, catchId, batchId, ""); err != nil {
+			return err
+		}
+	}
+	if err := unindexLotNumber(ctx, batch); err != nil {
+		return err
+	}
+
+	return ctx.GetStub().DelState("BATCH_" + batchId)
+}
+
+// catchBatchIndex is the composite key index of batch IDs by catch
+const catchBatchIndex = "catch~batch"
+
+// GetBatchForCatch returns the ID of the batch a catch was processed into
+func (s *SmartContract) GetBatchForCatch(ctx contractapi.TransactionContextInterface, catchId string) (string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(catchBatchIndex, []string{catchId})
+	if err != nil {
+		return "", fmt.Errorf("failed to get batch for catch %s: %v", catchId, err)
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return "", notFound("catch %s not assigned to any batch", catchId)
+	}
+	queryResponse, err := resultsIterator.Next()
+	if err != nil {
+		return "", fmt.Errorf("failed during results iteration: %v", err)
+	}
+	_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to split composite key: %v", err)
+	}
+
+	return keyParts[1], nil
+}
+
+// GetBatchesContainingCatch returns every batch the catch~batch index lists for a catch, for recalls
+func (s *SmartContract) GetBatchesContainingCatch(ctx contractapi.TransactionContextInterface, catchId string) ([]*Batch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(catchBatchIndex, []string{catchId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches for catch %s: %v", catchId, err)
+	}
+	defer resultsIterator.Close()
+
+	batches := []*Batch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+
+		batch, err := s.GetBatch(ctx, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+// GetUnbatchedCatches returns unarchived catches not yet assigned to any batch
+func (s *SmartContract) GetUnbatchedCatches(ctx contractapi.TransactionContextInterface) ([]*Catch, error) {
+	indexIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(catchBatchIndex, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batched catches: %v", err)
+	}
+	defer indexIterator.Close()
+
+	batched := map[string]bool{}
+	for indexIterator.HasNext() {
+		queryResponse, err := indexIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+		batched[keyParts[0]] = true
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	catches := []*Catch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+
+		if !batched[catch.CatchID] && !catch.Archived {
+			catches = append(catches, &catch)
+		}
+	}
+
+	return catches, nil
+}
+
+// moveCatchBatch updates the catch~batch index when a catch leaves fromBatchId for toBatchId;
+// an empty ID on either side only adds or only removes the entry
+func moveCatchBatch(ctx contractapi.TransactionContextInterface, catchId, fromBatchId, toBatchId string) error {
+	if fromBatchId != "" {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(catchBatchIndex, []string{catchId, fromBatchId})
+		if err != nil {
+			return fmt.Errorf("failed to create composite key: %v", err)
+		}
+		if err := ctx.GetStub().DelState(indexKey); err != nil {
+			return fmt.Errorf("failed to unindex catch %s: %v", catchId, err)
+		}
+	}
+	if toBatchId != "" {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(catchBatchIndex, []string{catchId, toBatchId})
+		if err != nil {
+			return fmt.Errorf("failed to create composite key: %v", err)
+		}
+		if err := ctx.GetStub().PutState(indexKey, indexValue); err != nil {
+			return fmt.Errorf("failed to index catch %s: %v", catchId, err)
+		}
+	}
+	return nil
+}
+
+// batchHasOrders reports whether any order references the batch
+func batchHasOrders(ctx contractapi.TransactionContextInterface, batchId string) (bool, error) {
+	orders, err := ordersMatching(ctx, func(order *Order) bool { return order.hasBatch(batchId) })
+	if err != nil {
+		return false, err
+	}
+	return len(orders) > 0, nil
+}
+
+// GetExpiringBatches returns unarchived batches whose best-before date is on or before asOfDate
+// and which have not been delivered on any order
+func (s *SmartContract) GetExpiringBatches(ctx contractapi.TransactionContextInterface, asOfDate string) ([]*Batch, error) {
+	if err := validateDate(asOfDate); err != nil {
+		return nil, err
+	}
+
+	deliveredOrders, err := ordersMatching(ctx, func(order *Order) bool { return order.Status == "delivered" })
+	if err != nil {
+		return nil, err
+	}
+	delivered := map[string]bool{}
+	for _, order := range deliveredOrders {
+		for _, batchId := range order.BatchIDs {
+			delivered[batchId] = true
+		}
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("BATCH_", prefixEnd("BATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	batches := []*Batch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var batch Batch
+		err = json.Unmarshal(queryResponse.Value, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal batch data: %v", err)
+		}
+
+		if batch.BestBefore == "" || batch.BestBefore > asOfDate || batch.Archived || delivered[batch.BatchID] {
+			continue
+		}
+		batches = append(batches, &batch)
+	}
+
+	return batches, nil
+}
+
+// lotSequenceKey keys the last lot sequence number issued to a processor on a batch date
+const lotSequenceKey = "lotseq"
+
+// lotBatchIndex is the composite key index of batch IDs by lot number
+const lotBatchIndex = "lot~batch"
+
+// assignLotNumber labels a new batch PROCESSORID-YYYYMMDD-NNN, taking the next number in the
+// processor's sequence for the batch date and indexing the batch under it. Numbers are never
+// reused, so deleted or merged batches leave gaps rather than collisions.
+func assignLotNumber(ctx contractapi.TransactionContextInterface, batch *Batch) error {
+	day := strings.ReplaceAll(batch.Date, "-", "")
+	seqKey, err := ctx.GetStub().CreateCompositeKey(lotSequenceKey, []string{batch.ProcessorID, day})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	seqBytes, err := ctx.GetStub().GetState(seqKey)
+	if err != nil {
+		return fmt.Errorf("failed to read lot sequence for %s: %v", batch.ProcessorID, err)
+	}
+	seq := 0
+	if seqBytes != nil {
+		seq, err = strconv.Atoi(string(seqBytes))
+		if err != nil {
+			return fmt.Errorf("invalid lot sequence stored for %s: %v", batch.ProcessorID, err)
+		}
+	}
+	seq++
+	if err := ctx.GetStub().PutState(seqKey, []byte(strconv.Itoa(seq))); err != nil {
+		return fmt.Errorf("failed to update lot sequence for %s: %v", batch.ProcessorID, err)
+	}
+
+	batch.LotNumber = fmt.Sprintf("%s-%s-%03d", batch.ProcessorID, day, seq)
+	indexKey, err := ctx.GetStub().CreateCompositeKey(lotBatchIndex, []string{batch.LotNumber, batch.BatchID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	if err := ctx.GetStub().PutState(indexKey, indexValue); err != nil {
+		return fmt.Errorf("failed to index lot %s: %v", batch.LotNumber, err)
+	}
+	return nil
+}
+
+// unindexLotNumber removes a batch's lot~batch entry; batches created before lot numbers have none
+func unindexLotNumber(ctx contractapi.TransactionContextInterface, batch *Batch) error {
+	if batch.LotNumber == "" {
+		return nil
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(lotBatchIndex, []string{batch.LotNumber, batch.BatchID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("failed to unindex lot %s: %v", batch.LotNumber, err)
+	}
+	return nil
+}
+
+// GetBatchByLotNumber returns the batch labelled with a lot number
+func (s *SmartContract) GetBatchByLotNumber(ctx contractapi.TransactionContextInterface, lotNumber string) (*Batch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(lotBatchIndex, []string{lotNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch for lot %s: %v", lotNumber, err)
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return nil, notFound("no batch with lot number %s", lotNumber)
+	}
+	queryResponse, err := resultsIterator.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed during results iteration: %v", err)
+	}
+	_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split composite key: %v", err)
+	}
+
+	return s.GetBatch(ctx, keyParts[1])
+}
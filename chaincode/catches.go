@@ -0,0 +1,840 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// HistoryEntry is one modification of a ledger key
+type HistoryEntry struct {
+	TxID      string          `json:"txId"`
+	Timestamp string          `json:"timestamp"`
+	IsDelete  bool            `json:"isDelete"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// GetCatchHistory returns every recorded modification of a catch as a JSON array.
+// Requires the peer history database (ledger.history.enableHistoryDatabase in core.yaml).
+func (s *SmartContract) GetCatchHistory(ctx contractapi.TransactionContextInterface, catchId string) (string, error) {
+	historyIterator, err := ctx.GetStub().GetHistoryForKey("CATCH_" + catchId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get history for catch %s: %v", catchId, err)
+	}
+	defer historyIterator.Close()
+
+	history := []HistoryEntry{}
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed during history iteration: %v", err)
+		}
+
+		entry := HistoryEntry{
+			TxID:      modification.TxId,
+			Timestamp: modification.Timestamp.AsTime().UTC().Format(time.RFC3339),
+			IsDelete:  modification.IsDelete,
+		}
+		if !modification.IsDelete {
+			entry.Value = json.RawMessage(modification.Value)
+		}
+		history = append(history, entry)
+	}
+
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal history data: %v", err)
+	}
+
+	return string(historyBytes), nil
+}
+
+// CatchPage is one page of catches plus the bookmark for the next page
+type CatchPage struct {
+	Catches  []Catch `json:"catches"`
+	Bookmark string  `json:"bookmark"`
+}
+
+// GetAllCatches lists every catch one page at a time; pass the returned bookmark to continue.
+// Archived catches are only included when includeArchived is set.
+func (s *SmartContract) GetAllCatches(ctx contractapi.TransactionContextInterface, pageSize int, bookmark string, includeArchived bool) (*CatchPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("pageSize must be positive")
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("CATCH_", prefixEnd("CATCH_"), int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &CatchPage{Catches: []Catch{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+		if catch.Archived && !includeArchived {
+			continue
+		}
+		page.Catches = append(page.Catches, catch)
+	}
+	page.Bookmark = metadata.Bookmark
+
+	return page, nil
+}
+
+// fisherCatchIndex is the composite key index of catch IDs by fisher
+const fisherCatchIndex = "fisher~catch"
+
+// indexValue is stored under index keys; Fabric treats an empty value as a delete
+var indexValue = []byte{0x00}
+
+// GetCatchesByFisher returns one page of a fisher's catches, resolved through the fisher~catch index.
+// species, when non-empty, filters the fetched page, so a page may hold fewer than pageSize catches;
+// archived catches are only included when includeArchived is set.
+func (s *SmartContract) GetCatchesByFisher(ctx contractapi.TransactionContextInterface, fisherId string, pageSize int, bookmark, species string, includeArchived bool) (*CatchPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("pageSize must be positive")
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(fisherCatchIndex, []string{fisherId}, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches for fisher %s: %v", fisherId, err)
+	}
+	defer resultsIterator.Close()
+
+	page := &CatchPage{Catches: []Catch{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+
+		catch, err := s.GetCatch(ctx, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if catch.Archived && !includeArchived {
+			continue
+		}
+		if species != "" && catch.Species != species {
+			continue
+		}
+		page.Catches = append(page.Catches, *catch)
+	}
+	page.Bookmark = metadata.Bookmark
+
+	return page, nil
+}
+
+// fisherCatches returns every unarchived catch logged by the given fisher
+func (s *SmartContract) fisherCatches(ctx contractapi.TransactionContextInterface, fisherId string) ([]*Catch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(fisherCatchIndex, []string{fisherId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches for fisher %s: %v", fisherId, err)
+	}
+	defer resultsIterator.Close()
+
+	catches := []*Catch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+
+		catch, err := s.GetCatch(ctx, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		if !catch.Archived {
+			catches = append(catches, catch)
+		}
+	}
+
+	return catches, nil
+}
+
+// DeleteCatch removes a catch record; restricted to authority for data corrections.
+// A catch still listed in a batch is refused so the batch and catch~batch index stay consistent.
+func (s *SmartContract) DeleteCatch(ctx contractapi.TransactionContextInterface, catchId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can delete catches")
+	}
+
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+
+	batchIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(catchBatchIndex, []string{catchId})
+	if err != nil {
+		return fmt.Errorf("failed to get batch for catch %s: %v", catchId, err)
+	}
+	defer batchIterator.Close()
+	if batchIterator.HasNext() {
+		queryResponse, err := batchIterator.Next()
+		if err != nil {
+			return fmt.Errorf("failed during results iteration: %v", err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return fmt.Errorf("failed to split composite key: %v", err)
+		}
+		return fmt.Errorf("catch %s is in batch %s; remove it from the batch before deleting", catchId, keyParts[1])
+	}
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(fisherCatchIndex, []string{catch.FisherID, catchId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("failed to delete fisher index for catch %s: %v", catchId, err)
+	}
+
+	// Archived catches were already taken off the species count
+	if !catch.Archived {
+		if err := adjustSpeciesCount(ctx, catch.Species, -1); err != nil {
+			return err
+		}
+	}
+
+	return ctx.GetStub().DelState("CATCH_" + catchId)
+}
+
+// ReassignCatch attributes a mis-recorded catch to the correct fisher, moving its fisher~catch
+// index entry and giving it the next number in the new fisher's sequence. The previous fisher
+// is kept in ReassignedFrom and in the catch's key history.
+func (s *SmartContract) ReassignCatch(ctx contractapi.TransactionContextInterface, catchId, newFisherId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can reassign catches")
+	}
+
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+	if catch.FisherID == newFisherId {
+		return fmt.Errorf("catch %s already belongs to fisher %s", catchId, newFisherId)
+	}
+
+	fisher, err := readFisher(ctx, newFisherId)
+	if err != nil {
+		return err
+	}
+	if !fisher.Active {
+		return fmt.Errorf("fisher %s is not active", newFisherId)
+	}
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(fisherCatchIndex, []string{catch.FisherID, catchId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	if err := ctx.GetStub().DelState(indexKey); err != nil {
+		return fmt.Errorf("failed to delete fisher index for catch %s: %v", catchId, err)
+	}
+
+	if catch.SeqNo > 0 {
+		reassignedKey, err := ctx.GetStub().CreateCompositeKey(reassignedSeqIndex, []string{catch.FisherID, strconv.Itoa(catch.SeqNo)})
+		if err != nil {
+			return fmt.Errorf("failed to create composite key: %v", err)
+		}
+		if err := ctx.GetStub().PutState(reassignedKey, indexValue); err != nil {
+			return fmt.Errorf("failed to record reassignment of catch %s: %v", catchId, err)
+		}
+	}
+	lastSeq, err := lastCatchSeq(ctx, newFisherId)
+	if err != nil {
+		return err
+	}
+	if err := setCatchSeq(ctx, newFisherId, lastSeq+1); err != nil {
+		return err
+	}
+
+	catch.ReassignedFrom = catch.FisherID
+	catch.FisherID = newFisherId
+	catch.MSPID = fisher.MSPID
+	catch.SeqNo = lastSeq + 1
+	if err := putCatch(ctx, catch); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventCatchReassigned, catchId)
+}
+
+// UpdateCatch corrects the species and weight of a catch, preserving its fisher and date.
+// Only the fisher who logged it or an authority may update; any verification is cleared.
+func (s *SmartContract) UpdateCatch(ctx contractapi.TransactionContextInterface, catchId, species, weightKgStr string) error {
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+
+	if !s.hasRole(ctx, "authority") && !s.isCaller(ctx, catch.FisherID) {
+		return unauthorized("only the fisher or an authority can update a catch")
+	}
+
+	weightKg, err := strconv.ParseFloat(weightKgStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid weightKg value '%s': %v", weightKgStr, err)
+	}
+	if weightKg <= 0 {
+		return fmt.Errorf("weight must be positive")
+	}
+	if err := checkSpeciesCode(ctx, species); err != nil {
+		return err
+	}
+
+	if species != catch.Species && !catch.Archived {
+		if err := adjustSpeciesCount(ctx, catch.Species, -1); err != nil {
+			return err
+		}
+		if err := adjustSpeciesCount(ctx, species, 1); err != nil {
+			return err
+		}
+	}
+	catch.Species = species
+	catch.WeightKg = roundWeightKg(weightKg)
+	// An edited catch no longer matches what the authority attested
+	catch.Verified = false
+	catch.VerifiedBy = ""
+	catch.VerifiedAt = ""
+
+	catchBytes, err := json.Marshal(catch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal catch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CATCH_"+catchId, catchBytes)
+}
+
+// VerifyCatch records an authority's attestation of a catch
+func (s *SmartContract) VerifyCatch(ctx contractapi.TransactionContextInterface, catchId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can verify catches")
+	}
+
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+
+	verifiedBy, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	verifiedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	catch.Verified = true
+	catch.VerifiedBy = verifiedBy
+	catch.VerifiedAt = verifiedAt
+
+	catchBytes, err := json.Marshal(catch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal catch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CATCH_"+catchId, catchBytes)
+}
+
+// CondemnCatch marks a catch as unfit after inspection; condemned catches cannot be batched
+func (s *SmartContract) CondemnCatch(ctx contractapi.TransactionContextInterface, catchId, reason string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can condemn catches")
+	}
+
+	if err := requireNonEmpty("reason", reason); err != nil {
+		return err
+	}
+
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return err
+	}
+	catch.Condemned = true
+	catch.CondemnReason = reason
+
+	catchBytes, err := json.Marshal(catch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal catch data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CATCH_"+catchId, catchBytes)
+}
+
+// LogCatchBatch logs a JSON array of catches in one transaction.
+// Every entry is validated before any is written; if one is invalid nothing is stored.
+// Only the fields LogCatch accepts are taken from each entry; verification, condemnation,
+// archival, reassignment, MSP and sequence fields are always set by the contract.
+func (s *SmartContract) LogCatchBatch(ctx contractapi.TransactionContextInterface, catchesJSON string) error {
+	var catches []Catch
+	if err := json.Unmarshal([]byte(catchesJSON), &catches); err != nil {
+		return fmt.Errorf("failed to unmarshal catches: %v", err)
+	}
+	if len(catches) == 0 {
+		return fmt.Errorf("no catches to log")
+	}
+
+	// Reads in a transaction don't see its own writes, so quota usage within the batch is tracked here
+	pendingKg := make(map[string]float64)
+	pendingDailyKg := make(map[string]float64)
+	seen := make(map[string]bool)
+	var problems []string
+	for i := range catches {
+		in := catches[i]
+		catches[i] = Catch{
+			CatchID:      in.CatchID,
+			FisherID:     in.FisherID,
+			VesselID:     in.VesselID,
+			Species:      in.Species,
+			WeightKg:     roundWeightKg(in.WeightKg),
+			Date:         in.Date,
+			Latitude:     in.Latitude,
+			Longitude:    in.Longitude,
+			Grade:        in.Grade,
+			StorageTempC: in.StorageTempC,
+		}
+		catch := &catches[i]
+		if seen[catch.CatchID] {
+			problems = append(problems, fmt.Sprintf("catch %s listed more than once", catch.CatchID))
+			continue
+		}
+		seen[catch.CatchID] = true
+		if err := s.validateCatch(ctx, catch, pendingKg[catch.Species]); err != nil {
+			problems = append(problems, fmt.Sprintf("catch %s: %v", catch.CatchID, err))
+			continue
+		}
+		day := catch.FisherID + "|" + catch.Date
+		if err := s.checkDailyCatchLimit(ctx, catch, pendingDailyKg[day]); err != nil {
+			problems = append(problems, fmt.Sprintf("catch %s: %v", catch.CatchID, err))
+			continue
+		}
+		mspID, err := fisherMSPID(ctx, catch.FisherID)
+		if err != nil {
+			return err
+		}
+		catch.MSPID = mspID
+		pendingKg[catch.Species] += catch.WeightKg
+		pendingDailyKg[day] += catch.WeightKg
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d catches invalid: %s", len(problems), len(catches), strings.Join(problems, "; "))
+	}
+
+	recordedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	lastSeq := make(map[string]int)
+	var fishers []string
+	for i := range catches {
+		fisherId := catches[i].FisherID
+		if _, ok := lastSeq[fisherId]; !ok {
+			if lastSeq[fisherId], err = lastCatchSeq(ctx, fisherId); err != nil {
+				return err
+			}
+			fishers = append(fishers, fisherId)
+		}
+		lastSeq[fisherId]++
+		catches[i].SeqNo = lastSeq[fisherId]
+		catches[i].RecordedAt = recordedAt
+		if err := putCatch(ctx, &catches[i]); err != nil {
+			return err
+		}
+	}
+	for _, fisherId := range fishers {
+		if err := setCatchSeq(ctx, fisherId, lastSeq[fisherId]); err != nil {
+			return err
+		}
+	}
+	if err := addSpeciesCounts(ctx, catches); err != nil {
+		return err
+	}
+	for i := range catches {
+		if err := emitEvent(ctx, EventCatchLogged, catches[i].CatchID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCatchesBySpeciesAndDateRange returns unarchived catches of a species dated within [startDate, endDate].
+// Uses a CouchDB rich query when available and falls back to a range scan on LevelDB.
+func (s *SmartContract) GetCatchesBySpeciesAndDateRange(ctx contractapi.TransactionContextInterface, species, startDate, endDate string) ([]*Catch, error) {
+	if err := validateDate(startDate); err != nil {
+		return nil, err
+	}
+	if err := validateDate(endDate); err != nil {
+		return nil, err
+	}
+
+	queryBytes, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{
+			"catchId": map[string]interface{}{"$exists": true},
+			"species": species,
+			"date":    map[string]interface{}{"$gte": startDate, "$lte": endDate},
+		},
+		"use_index": []string{"_design/indexSpeciesDateDoc", "indexSpeciesDate"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build catch query: %v", err)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryBytes))
+	if err != nil {
+		// LevelDB doesn't support rich queries; filter a range scan instead
+		inRange, err := catchesInRange(ctx, startDate, endDate, false)
+		if err != nil {
+			return nil, err
+		}
+		catches := []*Catch{}
+		for i := range inRange {
+			if inRange[i].Species == species {
+				catches = append(catches, &inRange[i])
+			}
+		}
+		return catches, nil
+	}
+	defer resultsIterator.Close()
+
+	catches := []*Catch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+		if !catch.Archived {
+			catches = append(catches, &catch)
+		}
+	}
+
+	return catches, nil
+}
+
+// PutCatchPrivate stores a catch in a private data collection. catchJSON is kept byte for byte,
+// so its SHA-256 is the hash other orgs see on chain and can check with VerifyCatchHash.
+func (s *SmartContract) PutCatchPrivate(ctx contractapi.TransactionContextInterface, collection, catchJSON string) error {
+	if err := requireNonEmpty("collection", collection); err != nil {
+		return err
+	}
+
+	var catch Catch
+	if err := json.Unmarshal([]byte(catchJSON), &catch); err != nil {
+		return fmt.Errorf("failed to unmarshal catch data: %v", err)
+	}
+	if err := requireNonEmpty("catchId", catch.CatchID); err != nil {
+		return err
+	}
+
+	err := ctx.GetStub().PutPrivateData(collection, "CATCH_"+catch.CatchID, []byte(catchJSON))
+	if err != nil {
+		return fmt.Errorf("failed to put private catch %s: %v", catch.CatchID, err)
+	}
+	return nil
+}
+
+// VerifyCatchHash reports whether a privately stored catch hashes to expectedHashHex (hex SHA-256).
+// Only the on-chain hash is read, so callers outside the collection can verify data received off-chain.
+func (s *SmartContract) VerifyCatchHash(ctx contractapi.TransactionContextInterface, collection, catchId, expectedHashHex string) (bool, error) {
+	expectedHash, err := hex.DecodeString(expectedHashHex)
+	if err != nil {
+		return false, fmt.Errorf("invalid hash %q: %v", expectedHashHex, err)
+	}
+
+	hash, err := ctx.GetStub().GetPrivateDataHash(collection, "CATCH_"+catchId)
+	if err != nil {
+		return false, fmt.Errorf("failed to read hash of private catch %s: %v", catchId, err)
+	}
+	if hash == nil {
+		return false, notFound("private catch %s not found in %s", catchId, collection)
+	}
+
+	return bytes.Equal(hash, expectedHash), nil
+}
+
+// GetCatchCountBySpecies returns the number of unarchived catches of a species by summing its count deltas
+func (s *SmartContract) GetCatchCountBySpecies(ctx contractapi.TransactionContextInterface, species string) (int, error) {
+	return speciesCount(ctx, species)
+}
+
+// speciesDeltaIndex keys per-transaction changes to a species' catch count by species and
+// transaction ID. Writers never read a shared counter, so concurrent LogCatch calls don't
+// hit MVCC read conflicts; readers sum the deltas instead.
+const speciesDeltaIndex = "species~delta"
+
+// speciesCount sums the count deltas recorded for a species
+func speciesCount(ctx contractapi.TransactionContextInterface, species string) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(speciesDeltaIndex, []string{species})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get catch count deltas for %s: %v", species, err)
+	}
+	defer resultsIterator.Close()
+
+	count := 0
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		delta, err := strconv.Atoi(string(queryResponse.Value))
+		if err != nil {
+			return 0, fmt.Errorf("invalid catch count delta stored for %s: %v", species, err)
+		}
+		count += delta
+	}
+	return count, nil
+}
+
+// adjustSpeciesCount records a change to a species' catch count under this transaction's ID.
+// A second call for the same species in one transaction replaces the first, so aggregate first.
+func adjustSpeciesCount(ctx contractapi.TransactionContextInterface, species string, delta int) error {
+	deltaKey, err := ctx.GetStub().CreateCompositeKey(speciesDeltaIndex, []string{species, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutState(deltaKey, []byte(strconv.Itoa(delta)))
+	if err != nil {
+		return fmt.Errorf("failed to update catch count for %s: %v", species, err)
+	}
+	return nil
+}
+
+// addSpeciesCounts increments the species counters for catches written in one transaction
+func addSpeciesCounts(ctx contractapi.TransactionContextInterface, catches []Catch) error {
+	added := make(map[string]int)
+	var species []string
+	for _, catch := range catches {
+		if added[catch.Species] == 0 {
+			species = append(species, catch.Species)
+		}
+		added[catch.Species]++
+	}
+	for _, name := range species {
+		if err := adjustSpeciesCount(ctx, name, added[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fisherMSPID returns the MSP a fisher was registered under, which their catches inherit
+func fisherMSPID(ctx contractapi.TransactionContextInterface, fisherId string) (string, error) {
+	fisher, err := readFisher(ctx, fisherId)
+	if err != nil {
+		return "", err
+	}
+	return fisher.MSPID, nil
+}
+
+// GetCatchesByMSP returns unarchived catches by fishers registered under an MSP.
+// Callers may only query their own MSP unless they are an authority.
+func (s *SmartContract) GetCatchesByMSP(ctx contractapi.TransactionContextInterface, mspId string) ([]*Catch, error) {
+	if !s.hasRole(ctx, "authority") {
+		callerMSP, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read caller MSP ID: %v", err)
+		}
+		if callerMSP != mspId {
+			return nil, unauthorized("only members of %s or an authority can view its catches", mspId)
+		}
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	catches := []*Catch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+		if catch.MSPID == mspId && !catch.Archived {
+			catches = append(catches, &catch)
+		}
+	}
+
+	return catches, nil
+}
+
+// speciesTotalKg sums the weight of every logged catch of a species that counts against its quota.
+// Condemned catches are left out; archived ones still count, since archiving only hides a landed catch.
+func speciesTotalKg(ctx contractapi.TransactionContextInterface, species string) (float64, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var totalKg float64
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+
+		if catch.Species == species && !catch.Condemned {
+			totalKg += catch.WeightKg
+		}
+	}
+
+	return totalKg, nil
+}
+
+// catchSeqKey keys the sequence number of a fisher's most recent catch
+const catchSeqKey = "catchseq"
+
+// reassignedSeqIndex records sequence numbers that left a fisher through ReassignCatch,
+// so VerifyFisherSequence doesn't mistake them for deletions
+const reassignedSeqIndex = "fisher~reassignedseq"
+
+// lastCatchSeq returns the sequence number issued to a fisher's latest catch, or 0 before their first
+func lastCatchSeq(ctx contractapi.TransactionContextInterface, fisherId string) (int, error) {
+	seqKey, err := ctx.GetStub().CreateCompositeKey(catchSeqKey, []string{fisherId})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	seqBytes, err := ctx.GetStub().GetState(seqKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read catch sequence for fisher %s: %v", fisherId, err)
+	}
+	if seqBytes == nil {
+		return 0, nil
+	}
+	seq, err := strconv.Atoi(string(seqBytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid catch sequence stored for fisher %s: %v", fisherId, err)
+	}
+	return seq, nil
+}
+
+// setCatchSeq stores the sequence number of a fisher's latest catch
+func setCatchSeq(ctx contractapi.TransactionContextInterface, fisherId string, seq int) error {
+	seqKey, err := ctx.GetStub().CreateCompositeKey(catchSeqKey, []string{fisherId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	if err := ctx.GetStub().PutState(seqKey, []byte(strconv.Itoa(seq))); err != nil {
+		return fmt.Errorf("failed to update catch sequence for fisher %s: %v", fisherId, err)
+	}
+	return nil
+}
+
+// FisherSequenceCheck reports the catch sequence numbers missing from a fisher's record
+type FisherSequenceCheck struct {
+	FisherID  string `json:"fisherId"`
+	LastSeqNo int    `json:"lastSeqNo"`
+	Missing   []int  `json:"missing"`
+	Complete  bool   `json:"complete"`
+}
+
+// VerifyFisherSequence walks a fisher's catches, archived ones included, and lists any sequence
+// number up to the latest issued that no catch carries; a gap indicates a deleted catch.
+// Catches logged before sequence numbering have no SeqNo and are ignored.
+func (s *SmartContract) VerifyFisherSequence(ctx contractapi.TransactionContextInterface, fisherId string) (*FisherSequenceCheck, error) {
+	if !s.hasRole(ctx, "authority") {
+		return nil, unauthorized("only authority can verify catch sequences")
+	}
+
+	lastSeq, err := lastCatchSeq(ctx, fisherId)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[int]bool)
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(fisherCatchIndex, []string{fisherId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches for fisher %s: %v", fisherId, err)
+	}
+	defer resultsIterator.Close()
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+		catch, err := s.GetCatch(ctx, keyParts[1])
+		if err != nil {
+			return nil, err
+		}
+		present[catch.SeqNo] = true
+	}
+
+	reassignedIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(reassignedSeqIndex, []string{fisherId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reassigned catches for fisher %s: %v", fisherId, err)
+	}
+	defer reassignedIterator.Close()
+	for reassignedIterator.HasNext() {
+		queryResponse, err := reassignedIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split composite key: %v", err)
+		}
+		seq, err := strconv.Atoi(keyParts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reassigned sequence number %q: %v", keyParts[1], err)
+		}
+		present[seq] = true
+	}
+
+	check := &FisherSequenceCheck{FisherID: fisherId, LastSeqNo: lastSeq, Missing: []int{}}
+	for seq := 1; seq <= lastSeq; seq++ {
+		if !present[seq] {
+			check.Missing = append(check.Missing, seq)
+		}
+	}
+	check.Complete = len(check.Missing) == 0
+
+	return check, nil
+}
@@ -7,7 +7,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -16,35 +20,97 @@ import (
 type Fisher struct {
 	ID     string `json:"id"`
 	Name   string `json:"name"`
-	GovtID string `json:"govtId"`
+	GovtID string `json:"govtId,omitempty"`
 	Role   string `json:"role"`
+	Region string `json:"region,omitempty"`
+	MSPID  string `json:"mspId,omitempty"`
+	Active bool   `json:"active"`
 }
 
 // Catch represents a fish catch record
 type Catch struct {
-	CatchID  string  `json:"catchId"`
-	FisherID string  `json:"fisherId"`
-	Species  string  `json:"species"`
-	WeightKg float64 `json:"weightKg"`
-	Date     string  `json:"date"`
+	CatchID        string  `json:"catchId"`
+	FisherID       string  `json:"fisherId"`
+	SeqNo          int     `json:"seqNo,omitempty"`
+	ReassignedFrom string  `json:"reassignedFrom,omitempty"`
+	MSPID          string  `json:"mspId,omitempty"`
+	VesselID       string  `json:"vesselId,omitempty"`
+	Species        string  `json:"species"`
+	WeightKg       float64 `json:"weightKg"`
+	Date           string  `json:"date"`
+	Latitude       float64 `json:"lat"`
+	Longitude      float64 `json:"lng"`
+	Grade          string  `json:"grade,omitempty"`
+	StorageTempC   float64 `json:"storageTempC"`
+	RecordedAt     string  `json:"recordedAt"`
+	Verified       bool    `json:"verified"`
+	VerifiedBy     string  `json:"verifiedBy,omitempty"`
+	VerifiedAt     string  `json:"verifiedAt,omitempty"`
+	Condemned      bool    `json:"condemned,omitempty"`
+	CondemnReason  string  `json:"condemnReason,omitempty"`
+	Archived       bool    `json:"archived,omitempty"`
 }
 
 // Batch represents a batch of catches processed together
 type Batch struct {
-	BatchID     string   `json:"batchId"`
-	CatchIDs    []string `json:"catchIds"`
-	ProcessorID string   `json:"processorId"`
-	Date        string   `json:"date"`
-	QRCodeURL   string   `json:"qrCodeUrl"`
+	BatchID          string        `json:"batchId"`
+	CatchIDs         []string      `json:"catchIds"`
+	ProcessorID      string        `json:"processorId"`
+	LotNumber        string        `json:"lotNumber,omitempty"`
+	Date             string        `json:"date"`
+	QRCodeURL        string        `json:"qrCodeUrl"`
+	ParentBatchID    string        `json:"parentBatchId,omitempty"`
+	MergedFrom       []string      `json:"mergedFrom,omitempty"`
+	BestBefore       string        `json:"bestBefore,omitempty"`
+	DeclaredWeightKg float64       `json:"declaredWeightKg,omitempty"`
+	Certificates     []Certificate `json:"certificates,omitempty"`
+	OnHold           bool          `json:"onHold,omitempty"`
+	HoldReason       string        `json:"holdReason,omitempty"`
+	RecordedAt       string        `json:"recordedAt"`
+	ReceivedAt       string        `json:"receivedAt,omitempty"`
+	ProcessedAt      string        `json:"processedAt,omitempty"`
+	ShippedAt        string        `json:"shippedAt,omitempty"`
+	Archived         bool          `json:"archived,omitempty"`
 }
 
 // Order represents an order placed for a batch
 type Order struct {
-	OrderID string `json:"orderId"`
-	BatchID string `json:"batchId"`
-	BuyerID string `json:"buyerId"`
-	Status  string `json:"status"`
-	Date    string `json:"date"`
+	OrderID         string             `json:"orderId"`
+	BatchID         string             `json:"batchId"`
+	BatchIDs        []string           `json:"batchIds"`
+	BuyerID         string             `json:"buyerId"`
+	Status          string             `json:"status"`
+	Date            string             `json:"date"`
+	OrderQuantityKg float64            `json:"orderQuantityKg,omitempty"`
+	FulfilledKg     float64            `json:"fulfilledKg,omitempty"`
+	AllocatedKg     map[string]float64 `json:"allocatedKg,omitempty"` // batch ID -> kg of that batch claimed by this order
+	Amount          float64            `json:"amount,omitempty"`
+	Currency        string             `json:"currency,omitempty"`
+	RecordedAt      string             `json:"recordedAt"`
+	Archived        bool               `json:"archived,omitempty"`
+}
+
+// currencyCode matches ISO 4217 alphabetic currency codes such as USD or UGX
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validGrades are the quality grades a catch may be assigned
+var validGrades = map[string]bool{"A": true, "B": true, "C": true}
+
+// Plausible cold-chain storage temperature range in degrees Celsius
+const (
+	minStorageTempC = -40.0
+	maxStorageTempC = 40.0
+)
+
+// weightUnitsKg converts each accepted weight unit to kilograms; an empty unit means kg
+var weightUnitsKg = map[string]float64{"": 1, "kg": 1, "lb": 0.45359237}
+
+// weightScale rounds stored weights to three decimal places (grams) so reports don't carry float noise
+const weightScale = 1000
+
+// roundWeightKg rounds a weight to the stored precision of one gram
+func roundWeightKg(weightKg float64) float64 {
+	return math.Round(weightKg*weightScale) / weightScale
 }
 
 // SmartContract provides functions for managing the fisheries system
@@ -55,7 +121,34 @@ type SmartContract struct {
 // RegisterFisher allows an authority to register a new fisher (stored in private data)
 func (s *SmartContract) RegisterFisher(ctx contractapi.TransactionContextInterface, id, name, govtId string) error {
 	if !s.hasRole(ctx, "authority") {
-		return fmt.Errorf("only authority can register fishers")
+		return unauthorized("only authority can register fishers")
+	}
+
+	if err := requireNonEmpty("id", id); err != nil {
+		return err
+	}
+	if err := requireMaxLength("id", id, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("name", name, maxNameLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("govtId", govtId, maxIDLength); err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetPrivateData("FisherCollection", "FISHER_"+id)
+	if err != nil {
+		return fmt.Errorf("failed to read fisher %s: %v", id, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("fisher %s already exists", id)
+	}
+
+	// The registering org is recorded so it can see the fisher's catches via GetCatchesByMSP
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read caller MSP ID: %v", err)
 	}
 
 	fisher := Fisher{
@@ -63,25 +156,56 @@ func (s *SmartContract) RegisterFisher(ctx contractapi.TransactionContextInterfa
 		Name:   name,
 		GovtID: govtId,
 		Role:   "fisher",
+		MSPID:  mspID,
+		Active: true,
 	}
 
-	fisherBytes, err := json.Marshal(fisher)
-	if err != nil {
-		return fmt.Errorf("failed to marshal fisher: %v", err)
+	if err := putFisher(ctx, &fisher); err != nil {
+		return err
 	}
 
-	// Store in private data collection "FisherCollection"
-	return ctx.GetStub().PutPrivateData("FisherCollection", "FISHER_"+id, fisherBytes)
+	return putGovtIDIndex(ctx, govtId, id)
 }
 
-// GetFisher retrieves a fisher by ID from private data collection
+// GetFisher retrieves a fisher by ID from private data collections.
+// GovtID is only looked up in FisherPIICollection for an authority or the fisher themselves.
 func (s *SmartContract) GetFisher(ctx contractapi.TransactionContextInterface, fisherID string) (*Fisher, error) {
+	fisher, err := readFisher(ctx, fisherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.hasRole(ctx, "authority") && !s.isCaller(ctx, fisherID) {
+		fisher.GovtID = ""
+		return fisher, nil
+	}
+
+	if err := withGovtID(ctx, fisher); err != nil {
+		return nil, err
+	}
+	return fisher, nil
+}
+
+// GetFisherPublic retrieves a fisher for general display, always without GovtID
+func (s *SmartContract) GetFisherPublic(ctx contractapi.TransactionContextInterface, fisherID string) (*Fisher, error) {
+	fisher, err := readFisher(ctx, fisherID)
+	if err != nil {
+		return nil, err
+	}
+
+	fisher.GovtID = ""
+	return fisher, nil
+}
+
+// readFisher loads a fisher's basic record from FisherCollection. GovtID is empty
+// unless the record predates FisherPIICollection; see withGovtID.
+func readFisher(ctx contractapi.TransactionContextInterface, fisherID string) (*Fisher, error) {
 	fisherBytes, err := ctx.GetStub().GetPrivateData("FisherCollection", "FISHER_"+fisherID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read fisher %s: %v", fisherID, err)
 	}
 	if fisherBytes == nil {
-		return nil, fmt.Errorf("fisher %s does not exist", fisherID)
+		return nil, notFound("fisher %s does not exist", fisherID)
 	}
 
 	var fisher Fisher
@@ -94,48 +218,352 @@ func (s *SmartContract) GetFisher(ctx contractapi.TransactionContextInterface, f
 }
 
 // LogCatch logs a new catch record
-// weightKgStr is string because chaincode args are passed as strings; converted inside
-func (s *SmartContract) LogCatch(ctx contractapi.TransactionContextInterface, catchId, fisherId, species, weightKgStr, date string) error {
+// weightKgStr, latStr, lngStr and storageTempCStr are strings because chaincode args are passed as strings; converted inside
+// species is a code from the species registry, or free text while SetLegacySpeciesAllowed is on
+// vesselId may be empty for catches landed without a vessel; otherwise the vessel must be registered
+// unit is "kg" (the default when empty) or "lb"; the weight is always stored in kilograms, rounded to the gram
+// clientRequestId, when non-empty, makes retries of the same request succeed without writing again
+func (s *SmartContract) LogCatch(ctx contractapi.TransactionContextInterface, catchId, fisherId, species, weightKgStr, date, latStr, lngStr, vesselId, grade, storageTempCStr, unit, clientRequestId string) error {
 	// Uncomment when ready to enforce access control
 	/*
 		if !s.hasRole(ctx, "fisher") || !s.isCaller(ctx, fisherId) {
-			return fmt.Errorf("only the fisher can log their catch")
+			return unauthorized("only the fisher can log their catch")
 		}
 	*/
 
+	if seen, err := requestSeen(ctx, "LogCatch", clientRequestId, catchId); err != nil || seen {
+		return err
+	}
+
 	weightKg, err := strconv.ParseFloat(weightKgStr, 64)
 	if err != nil {
 		return fmt.Errorf("invalid weightKg value '%s': %v", weightKgStr, err)
 	}
+	toKg, ok := weightUnitsKg[unit]
+	if !ok {
+		return fmt.Errorf("unknown weight unit %q, expected kg or lb", unit)
+	}
+	weightKg = roundWeightKg(weightKg * toKg)
+
+	latitude, err := parseCoordinate("latitude", latStr, 90)
+	if err != nil {
+		return err
+	}
+	longitude, err := parseCoordinate("longitude", lngStr, 180)
+	if err != nil {
+		return err
+	}
+
+	storageTempC, err := strconv.ParseFloat(storageTempCStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid storageTempC value '%s': %v", storageTempCStr, err)
+	}
 
 	catch := Catch{
-		CatchID:  catchId,
-		FisherID: fisherId,
-		Species:  species,
-		WeightKg: weightKg,
-		Date:     date,
+		CatchID:      catchId,
+		FisherID:     fisherId,
+		VesselID:     vesselId,
+		Species:      species,
+		WeightKg:     weightKg,
+		Date:         date,
+		Latitude:     latitude,
+		Longitude:    longitude,
+		Grade:        grade,
+		StorageTempC: storageTempC,
+	}
+
+	if err := s.validateCatch(ctx, &catch, 0); err != nil {
+		return err
+	}
+	if err := s.checkDailyCatchLimit(ctx, &catch, 0); err != nil {
+		return err
+	}
+	if catch.MSPID, err = fisherMSPID(ctx, fisherId); err != nil {
+		return err
+	}
+	lastSeq, err := lastCatchSeq(ctx, fisherId)
+	if err != nil {
+		return err
+	}
+	catch.SeqNo = lastSeq + 1
+
+	catch.RecordedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := putCatch(ctx, &catch); err != nil {
+		return err
+	}
+	if err := setCatchSeq(ctx, fisherId, catch.SeqNo); err != nil {
+		return err
+	}
+	if err := adjustSpeciesCount(ctx, catch.Species, 1); err != nil {
+		return err
+	}
+
+	if err := recordRequest(ctx, "LogCatch", clientRequestId, catchId); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventCatchLogged, catchId)
+}
+
+// validateCatch applies every rule a new catch must satisfy without writing anything
+// pendingKg is weight of the same species not yet committed, counted against the quota
+func (s *SmartContract) validateCatch(ctx contractapi.TransactionContextInterface, catch *Catch, pendingKg float64) error {
+	if err := requireNonEmpty("catchId", catch.CatchID); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("fisherId", catch.FisherID); err != nil {
+		return err
+	}
+	if err := requireMaxLength("catchId", catch.CatchID, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("fisherId", catch.FisherID, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("vesselId", catch.VesselID, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("species", catch.Species, maxSpeciesLength); err != nil {
+		return err
+	}
+
+	exists, err := s.CatchExists(ctx, catch.CatchID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("catch %s already exists", catch.CatchID)
+	}
+
+	if err := validateDate(catch.Date); err != nil {
+		return err
+	}
+	if catch.WeightKg <= 0 {
+		return fmt.Errorf("weight must be positive")
+	}
+	if catch.Latitude < -90 || catch.Latitude > 90 {
+		return fmt.Errorf("latitude %g out of range [-90, 90]", catch.Latitude)
+	}
+	if catch.Longitude < -180 || catch.Longitude > 180 {
+		return fmt.Errorf("longitude %g out of range [-180, 180]", catch.Longitude)
+	}
+	if !validGrades[catch.Grade] {
+		return fmt.Errorf("invalid grade %q", catch.Grade)
+	}
+	if catch.StorageTempC < minStorageTempC || catch.StorageTempC > maxStorageTempC {
+		return fmt.Errorf("storage temperature %g out of range [%g, %g]", catch.StorageTempC, minStorageTempC, maxStorageTempC)
+	}
+	if err := checkSpeciesCode(ctx, catch.Species); err != nil {
+		return err
+	}
+
+	fisher, err := readFisher(ctx, catch.FisherID)
+	if err != nil {
+		return err
+	}
+	if !fisher.Active {
+		return fmt.Errorf("fisher %s is not active", catch.FisherID)
+	}
+	if fisher.Region != "" {
+		if err := checkRegionSpecies(ctx, fisher.Region, catch.Species); err != nil {
+			return err
+		}
+	}
+
+	protected, err := isEndangered(ctx, catch.Species)
+	if err != nil {
+		return err
+	}
+	if protected {
+		return fmt.Errorf("species %s is protected and cannot be logged", catch.Species)
+	}
+	if err := checkMinWeight(ctx, catch.Species, catch.WeightKg); err != nil {
+		return err
+	}
+
+	licensed, err := hasValidLicense(ctx, catch.FisherID, catch.Date)
+	if err != nil {
+		return err
+	}
+	if !licensed {
+		return fmt.Errorf("no valid license")
+	}
+
+	if catch.VesselID != "" {
+		exists, err := s.VesselExists(ctx, catch.VesselID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("vessel %s does not exist", catch.VesselID)
+		}
 	}
 
+	return s.checkSpeciesQuota(ctx, catch.Species, catch.WeightKg+pendingKg)
+}
+
+// putCatch writes a catch and its fisher index entry
+func putCatch(ctx contractapi.TransactionContextInterface, catch *Catch) error {
 	catchBytes, err := json.Marshal(catch)
 	if err != nil {
 		return fmt.Errorf("failed to marshal catch data: %v", err)
 	}
 
-	return ctx.GetStub().PutState("CATCH_"+catchId, catchBytes)
+	err = ctx.GetStub().PutState("CATCH_"+catch.CatchID, catchBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put catch %s: %v", catch.CatchID, err)
+	}
+
+	indexKey, err := ctx.GetStub().CreateCompositeKey(fisherCatchIndex, []string{catch.FisherID, catch.CatchID})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutState(indexKey, indexValue)
+	if err != nil {
+		return fmt.Errorf("failed to index catch %s: %v", catch.CatchID, err)
+	}
+	return nil
+}
+
+// GetCatch retrieves a catch by ID
+func (s *SmartContract) GetCatch(ctx contractapi.TransactionContextInterface, catchId string) (*Catch, error) {
+	catchBytes, err := ctx.GetStub().GetState("CATCH_" + catchId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catch %s: %v", catchId, err)
+	}
+	if catchBytes == nil {
+		return nil, notFound("catch %s not found", catchId)
+	}
+
+	var catch Catch
+	err = json.Unmarshal(catchBytes, &catch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+	}
+
+	return &catch, nil
+}
+
+// CatchExists returns true when a catch with the given ID is on the ledger
+func (s *SmartContract) CatchExists(ctx contractapi.TransactionContextInterface, catchId string) (bool, error) {
+	catchBytes, err := ctx.GetStub().GetState("CATCH_" + catchId)
+	if err != nil {
+		return false, fmt.Errorf("failed to read catch %s: %v", catchId, err)
+	}
+	return catchBytes != nil, nil
 }
 
 // CreateBatch creates a new batch record from catches
-func (s *SmartContract) CreateBatch(ctx contractapi.TransactionContextInterface, batchId string, catchIds []string, processorId, date string) error {
+// bestBefore may be empty, in which case it is derived from the shortest shelf life among the catches' species
+// declaredWeightKgStr is the processor's claimed weight, or empty; it is not checked here, see ValidateBatchWeight
+// clientRequestId is optional; see LogCatch
+func (s *SmartContract) CreateBatch(ctx contractapi.TransactionContextInterface, batchId string, catchIds []string, processorId, date, bestBefore, declaredWeightKgStr, clientRequestId string) error {
 	if !s.hasRole(ctx, "processor") {
-		return fmt.Errorf("only processor can create batches")
+		return unauthorized("only processor can create batches")
+	}
+	if seen, err := requestSeen(ctx, "CreateBatch", clientRequestId, batchId); err != nil || seen {
+		return err
+	}
+
+	if err := requireNonEmpty("batchId", batchId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("processorId", processorId); err != nil {
+		return err
+	}
+	if err := requireMaxLength("batchId", batchId, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("processorId", processorId, maxIDLength); err != nil {
+		return err
+	}
+
+	exists, err := s.BatchExists(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("batch %s already exists", batchId)
+	}
+
+	if err := validateDate(date); err != nil {
+		return err
+	}
+
+	if len(catchIds) == 0 {
+		return fmt.Errorf("batch must contain at least one catch")
+	}
+	for _, catchId := range catchIds {
+		catchBytes, err := ctx.GetStub().GetState("CATCH_" + catchId)
+		if err != nil {
+			return fmt.Errorf("failed to read catch %s: %v", catchId, err)
+		}
+		if catchBytes == nil {
+			return fmt.Errorf("catch %s does not exist", catchId)
+		}
+		var catch Catch
+		if err := json.Unmarshal(catchBytes, &catch); err != nil {
+			return fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+		if catch.Condemned {
+			return fmt.Errorf("catch %s is condemned and cannot be batched", catchId)
+		}
+	}
+
+	if bestBefore == "" {
+		computed, err := s.batchBestBefore(ctx, date, catchIds)
+		if err != nil {
+			return err
+		}
+		bestBefore = computed
+	} else {
+		if err := validateDate(bestBefore); err != nil {
+			return err
+		}
+		if bestBefore < date {
+			return fmt.Errorf("best-before date %s is before batch date %s", bestBefore, date)
+		}
+	}
+
+	var declaredWeightKg float64
+	if declaredWeightKgStr != "" {
+		parsed, err := strconv.ParseFloat(declaredWeightKgStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid declaredWeightKg value '%s': %v", declaredWeightKgStr, err)
+		}
+		if parsed <= 0 {
+			return fmt.Errorf("declared weight must be positive")
+		}
+		declaredWeightKg = roundWeightKg(parsed)
+	}
+
+	recordedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	qrCodeURL, err := batchQRCodeURL(ctx, batchId)
+	if err != nil {
+		return err
 	}
 
 	batch := Batch{
-		BatchID:     batchId,
-		CatchIDs:    catchIds,
-		ProcessorID: processorId,
-		Date:        date,
-		QRCodeURL:   fmt.Sprintf("https://getreech.example.org/batch/%s", batchId),
+		BatchID:          batchId,
+		CatchIDs:         catchIds,
+		ProcessorID:      processorId,
+		Date:             date,
+		QRCodeURL:        qrCodeURL,
+		BestBefore:       bestBefore,
+		DeclaredWeightKg: declaredWeightKg,
+		RecordedAt:       recordedAt,
+		ReceivedAt:       recordedAt,
+	}
+	if err := assignLotNumber(ctx, &batch); err != nil {
+		return err
 	}
 
 	batchBytes, err := json.Marshal(batch)
@@ -143,33 +571,161 @@ func (s *SmartContract) CreateBatch(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to marshal batch data: %v", err)
 	}
 
-	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+	err = ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put batch %s: %v", batchId, err)
+	}
+
+	for _, catchId := range catchIds {
+		if err := moveCatchBatch(ctx, catchId, "", batchId); err != nil {
+			return err
+		}
+	}
+
+	if err := recordRequest(ctx, "CreateBatch", clientRequestId, batchId); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventBatchCreated, batchId)
 }
 
-// TrackBatch retrieves batch details
-func (s *SmartContract) TrackBatch(ctx contractapi.TransactionContextInterface, batchId string) (string, error) {
+// GetBatch retrieves a batch by ID
+func (s *SmartContract) GetBatch(ctx contractapi.TransactionContextInterface, batchId string) (*Batch, error) {
 	batchBytes, err := ctx.GetStub().GetState("BATCH_" + batchId)
 	if err != nil {
-		return "", fmt.Errorf("failed to get batch %s: %v", batchId, err)
+		return nil, fmt.Errorf("failed to get batch %s: %v", batchId, err)
 	}
 	if batchBytes == nil {
-		return "", fmt.Errorf("batch %s not found", batchId)
+		return nil, notFound("batch %s not found", batchId)
+	}
+
+	var batch Batch
+	err = json.Unmarshal(batchBytes, &batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch data: %v", err)
+	}
+
+	return &batch, nil
+}
+
+// TrackBatch retrieves batch details as JSON; kept for clients that predate GetBatch
+func (s *SmartContract) TrackBatch(ctx contractapi.TransactionContextInterface, batchId string) (string, error) {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return "", err
+	}
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal batch data: %v", err)
 	}
 	return string(batchBytes), nil
 }
 
+// BatchExists returns true when a batch with the given ID is on the ledger
+func (s *SmartContract) BatchExists(ctx contractapi.TransactionContextInterface, batchId string) (bool, error) {
+	batchBytes, err := ctx.GetStub().GetState("BATCH_" + batchId)
+	if err != nil {
+		return false, fmt.Errorf("failed to read batch %s: %v", batchId, err)
+	}
+	return batchBytes != nil, nil
+}
+
 // PlaceOrder places a new order for a batch
-func (s *SmartContract) PlaceOrder(ctx contractapi.TransactionContextInterface, orderId, batchId, buyerId, date string) error {
+// amountStr is the order value in currency, a three-letter ISO 4217 code
+// quantityKgStr is optional; orders with a quantity can be filled from further batches via FulfillFromBatch
+// clientRequestId is optional; see LogCatch
+func (s *SmartContract) PlaceOrder(ctx contractapi.TransactionContextInterface, orderId, batchId, buyerId, date, amountStr, currency, quantityKgStr, clientRequestId string) error {
 	if !s.hasRole(ctx, "buyer") {
-		return fmt.Errorf("only buyer can place orders")
+		return unauthorized("only buyer can place orders")
+	}
+	if seen, err := requestSeen(ctx, "PlaceOrder", clientRequestId, orderId); err != nil || seen {
+		return err
+	}
+
+	if err := requireNonEmpty("orderId", orderId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("batchId", batchId); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("buyerId", buyerId); err != nil {
+		return err
+	}
+	if err := requireMaxLength("orderId", orderId, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("batchId", batchId, maxIDLength); err != nil {
+		return err
+	}
+	if err := requireMaxLength("buyerId", buyerId, maxIDLength); err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetState("ORDER_" + orderId)
+	if err != nil {
+		return fmt.Errorf("failed to read order %s: %v", orderId, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("order %s already exists", orderId)
+	}
+
+	batchBytes, err := ctx.GetStub().GetState("BATCH_" + batchId)
+	if err != nil {
+		return fmt.Errorf("failed to read batch %s: %v", batchId, err)
+	}
+	if batchBytes == nil {
+		return fmt.Errorf("cannot place order: batch %s not found", batchId)
+	}
+
+	if err := s.checkOpenOrderLimit(ctx, buyerId); err != nil {
+		return err
+	}
+
+	if err := validateDate(date); err != nil {
+		return err
+	}
+
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount value '%s': %v", amountStr, err)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	}
+	if !currencyCode.MatchString(currency) {
+		return fmt.Errorf("invalid currency code %q, expected three uppercase letters", currency)
+	}
+
+	recordedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
 	}
 
 	order := Order{
-		OrderID: orderId,
-		BatchID: batchId,
-		BuyerID: buyerId,
-		Status:  "placed",
-		Date:    date,
+		OrderID:    orderId,
+		BatchID:    batchId,
+		BatchIDs:   []string{batchId},
+		BuyerID:    buyerId,
+		Status:     "placed",
+		Date:       date,
+		Amount:     amount,
+		Currency:   currency,
+		RecordedAt: recordedAt,
+	}
+
+	if quantityKgStr != "" {
+		quantityKg, err := strconv.ParseFloat(quantityKgStr, 64)
+		if err != nil {
+			return fmt.Errorf("invalid quantityKg value '%s': %v", quantityKgStr, err)
+		}
+		if quantityKg <= 0 {
+			return fmt.Errorf("quantity must be positive")
+		}
+		order.OrderQuantityKg = roundWeightKg(quantityKg)
+		if err := s.allocateFromBatch(ctx, &order, batchId); err != nil {
+			return err
+		}
 	}
 
 	orderBytes, err := json.Marshal(order)
@@ -177,37 +733,46 @@ func (s *SmartContract) PlaceOrder(ctx contractapi.TransactionContextInterface,
 		return fmt.Errorf("failed to marshal order data: %v", err)
 	}
 
-	return ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+
+	if err := recordRequest(ctx, "PlaceOrder", clientRequestId, orderId); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventOrderPlaced, orderId)
 }
 
-// GenerateReport generates a JSON report of catches between dates
-func (s *SmartContract) GenerateReport(ctx contractapi.TransactionContextInterface, startDate, endDate string) (string, error) {
-	if !s.hasRole(ctx, "authority") {
-		return "", fmt.Errorf("only authority can generate reports")
+// GetOrder retrieves an order by ID
+func (s *SmartContract) GetOrder(ctx contractapi.TransactionContextInterface, orderId string) (*Order, error) {
+	orderBytes, err := ctx.GetStub().GetState("ORDER_" + orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order %s: %v", orderId, err)
+	}
+	if orderBytes == nil {
+		return nil, notFound("order %s not found", orderId)
 	}
 
-	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", "CATCH_~")
+	var order Order
+	err = json.Unmarshal(orderBytes, &order)
 	if err != nil {
-		return "", fmt.Errorf("failed to get catches by range: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal order data: %v", err)
 	}
-	defer resultsIterator.Close()
 
-	var catches []Catch
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return "", fmt.Errorf("failed during results iteration: %v", err)
-		}
+	return &order, nil
+}
 
-		var catch Catch
-		err = json.Unmarshal(queryResponse.Value, &catch)
-		if err != nil {
-			return "", fmt.Errorf("failed to unmarshal catch data: %v", err)
-		}
+// GenerateReport generates a JSON report of catches between dates
+func (s *SmartContract) GenerateReport(ctx contractapi.TransactionContextInterface, startDate, endDate string, includeArchived bool) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can generate reports")
+	}
 
-		if catch.Date >= startDate && catch.Date <= endDate {
-			catches = append(catches, catch)
-		}
+	catches, err := catchesInRange(ctx, startDate, endDate, includeArchived)
+	if err != nil {
+		return "", err
 	}
 
 	reportBytes, err := json.Marshal(catches)
@@ -218,13 +783,109 @@ func (s *SmartContract) GenerateReport(ctx contractapi.TransactionContextInterfa
 	return string(reportBytes), nil
 }
 
-// hasRole checks if the caller has the specified role attribute
+// Identity attributes holding the caller's role, in order of precedence; "hf.Role" is the
+// Fabric CA built-in and is only consulted when the custom "role" attribute is absent
+const (
+	roleAttribute       = "role"
+	fabricRoleAttribute = "hf.Role"
+)
+
+// resolveRole returns the caller's role from the first role attribute present
+func resolveRole(ctx contractapi.TransactionContextInterface) (string, bool) {
+	for _, attr := range []string{roleAttribute, fabricRoleAttribute} {
+		val, found, err := ctx.GetClientIdentity().GetAttributeValue(attr)
+		if err == nil && found {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// rolesAttribute holds a comma-separated role list for identities acting in several roles
+const rolesAttribute = "roles"
+
+// hasRole checks if the caller has the specified role. When an MSP policy is set for the role
+// the caller's MSP decides; otherwise the single role attribute or the comma-separated roles
+// attribute must name it.
 func (s *SmartContract) hasRole(ctx contractapi.TransactionContextInterface, role string) bool {
-	val, found, err := ctx.GetClientIdentity().GetAttributeValue("role")
+	if allowed, found := mspPolicyAllows(ctx, role); found {
+		return allowed
+	}
+	if val, found := resolveRole(ctx); found && val == role {
+		return true
+	}
+	roles, found, err := ctx.GetClientIdentity().GetAttributeValue(rolesAttribute)
 	if err != nil || !found {
 		return false
 	}
-	return val == role
+	for _, r := range strings.Split(roles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// txTimestamp returns the transaction timestamp as RFC3339; unlike client-supplied dates
+// it is set by the proposal and identical on every endorsing peer
+func txTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return ts.AsTime().UTC().Format(time.RFC3339), nil
+}
+
+// prefixEnd returns the exclusive end key covering every key that starts with prefix.
+// A "~" sentinel would miss IDs containing bytes above '~', such as non-ASCII characters.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	end[len(end)-1]++
+	return string(end)
+}
+
+// Length limits on caller-supplied text, so a single transaction can't bloat state
+const (
+	maxIDLength      = 64
+	maxNameLength    = 128
+	maxSpeciesLength = 64
+)
+
+// requireMaxLength rejects a value longer than max bytes
+func requireMaxLength(name, value string, max int) error {
+	if len(value) > max {
+		return fmt.Errorf("field %s exceeds max length %d", name, max)
+	}
+	return nil
+}
+
+// requireNonEmpty rejects blank IDs, which would produce bare-prefix keys like "CATCH_"
+func requireNonEmpty(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("field %s must not be empty", name)
+	}
+	return nil
+}
+
+// validateDate checks that a business date is a real calendar day in YYYY-MM-DD form,
+// which GenerateReport relies on for lexical range comparison
+func validateDate(date string) error {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	return nil
+}
+
+// parseCoordinate parses a latitude/longitude string and checks it lies within [-limit, limit]
+func parseCoordinate(name, value string, limit float64) (float64, error) {
+	coord, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %v", name, value, err)
+	}
+	if coord < -limit || coord > limit {
+		return 0, fmt.Errorf("%s %s out of range [-%g, %g]", name, value, limit, limit)
+	}
+	return coord, nil
 }
 
 // isCaller checks if the caller's enrollment ID matches the provided ID
@@ -236,6 +897,18 @@ func (s *SmartContract) isCaller(ctx contractapi.TransactionContextInterface, id
 	return enrollmentID == id
 }
 
+// callerID returns the caller's enrollment ID, the same identity isCaller compares against
+func callerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	enrollmentID, found, err := ctx.GetClientIdentity().GetAttributeValue("hf.EnrollmentID")
+	if err != nil {
+		return "", fmt.Errorf("failed to read caller enrollment ID: %v", err)
+	}
+	if !found {
+		return "", fmt.Errorf("caller has no enrollment ID")
+	}
+	return enrollmentID, nil
+}
+
 func main() {
 	chaincode, err := contractapi.NewChaincode(&SmartContract{})
 	if err != nil {
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// configPrefix starts every configuration key, so GetConfig can range-scan them all
+const configPrefix = "CFG_"
+
+// GetConfig returns every configuration key and its stored value as one JSON object
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can view configuration")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange(configPrefix, prefixEnd(configPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to get configuration by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	config := map[string]string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed during results iteration: %v", err)
+		}
+		config[queryResponse.Key] = string(queryResponse.Value)
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal configuration: %v", err)
+	}
+
+	return string(configBytes), nil
+}
+
+// qrBaseURLKey holds the deployment-specific base URL for batch QR codes
+const qrBaseURLKey = "CFG_QR_BASE_URL"
+
+// defaultQRBaseURL is used until an authority sets a deployment-specific base
+const defaultQRBaseURL = "https://getreech.example.org/batch"
+
+// SetQRBaseURL sets the base URL that batch QR codes link to, e.g. https://trace.example.com/batch
+func (s *SmartContract) SetQRBaseURL(ctx contractapi.TransactionContextInterface, baseURL string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set the QR base URL")
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid QR base URL %q", baseURL)
+	}
+
+	return ctx.GetStub().PutState(qrBaseURLKey, []byte(strings.TrimRight(baseURL, "/")))
+}
+
+// batchQRCodeURL returns the public tracking URL encoded in a batch's QR code
+func batchQRCodeURL(ctx contractapi.TransactionContextInterface, batchId string) (string, error) {
+	baseBytes, err := ctx.GetStub().GetState(qrBaseURLKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read QR base URL: %v", err)
+	}
+
+	base := defaultQRBaseURL
+	if baseBytes != nil {
+		base = string(baseBytes)
+	}
+
+	return base + "/" + url.PathEscape(batchId), nil
+}
+
+// maxOpenOrdersKey holds the cap on open orders per buyer; unset means unlimited
+const maxOpenOrdersKey = "CFG_MAX_OPEN_ORDERS"
+
+// SetMaxOpenOrders caps how many non-terminal orders a single buyer may hold
+func (s *SmartContract) SetMaxOpenOrders(ctx contractapi.TransactionContextInterface, maxStr string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set the open order limit")
+	}
+
+	max, err := strconv.Atoi(maxStr)
+	if err != nil {
+		return fmt.Errorf("invalid max value '%s': %v", maxStr, err)
+	}
+	if max <= 0 {
+		return fmt.Errorf("open order limit must be positive")
+	}
+
+	return ctx.GetStub().PutState(maxOpenOrdersKey, []byte(strconv.Itoa(max)))
+}
+
+// maxOpenOrders returns the configured open order limit, or 0 when none is set
+func maxOpenOrders(ctx contractapi.TransactionContextInterface) (int, error) {
+	maxBytes, err := ctx.GetStub().GetState(maxOpenOrdersKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read open order limit: %v", err)
+	}
+	if maxBytes == nil {
+		return 0, nil
+	}
+	max, err := strconv.Atoi(string(maxBytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid open order limit stored: %v", err)
+	}
+	return max, nil
+}
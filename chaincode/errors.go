@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes carried by ContractError so clients can branch without matching message text
+const (
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeNotFound     = "NOT_FOUND"
+)
+
+// ContractError is an error with a stable machine-readable code.
+// Error() renders as "CODE: message" since only the string reaches the client SDK.
+type ContractError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ContractError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// unauthorized returns a ContractError for a caller lacking the required role or identity
+func unauthorized(format string, args ...interface{}) error {
+	return &ContractError{Code: CodeUnauthorized, Message: fmt.Sprintf(format, args...)}
+}
+
+// notFound returns a ContractError for a record missing from the ledger
+func notFound(format string, args ...interface{}) error {
+	return &ContractError{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// isNotFound reports whether err is a not-found ContractError, as opposed to a failed read
+func isNotFound(err error) bool {
+	var contractErr *ContractError
+	return errors.As(err, &contractErr) && contractErr.Code == CodeNotFound
+}
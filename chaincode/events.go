@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Event names emitted by the contract; SDK listeners subscribe on these
+const (
+	EventCatchLogged    = "CatchLogged"
+	EventBatchCreated   = "BatchCreated"
+	EventOrderPlaced    = "OrderPlaced"
+	EventOrderCancelled = "OrderCancelled"
+
+	EventFisherRegionTransferred = "FisherRegionTransferred"
+	EventCatchReassigned         = "CatchReassigned"
+)
+
+// EventPayload is the JSON body attached to every chaincode event
+type EventPayload struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+}
+
+// emitEvent sets a chaincode event for the record with the given ID, stamped with the tx time
+func emitEvent(ctx contractapi.TransactionContextInterface, name, id string) error {
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(EventPayload{ID: id, Timestamp: timestamp})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event payload: %v", err)
+	}
+
+	return ctx.GetStub().SetEvent(name, payload)
+}
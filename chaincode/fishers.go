@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DeactivateFisher suspends a fisher without deleting their history; inactive fishers cannot log catches
+func (s *SmartContract) DeactivateFisher(ctx contractapi.TransactionContextInterface, fisherId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can deactivate fishers")
+	}
+	return setFisherActive(ctx, fisherId, false)
+}
+
+// ReactivateFisher lifts a suspension placed by DeactivateFisher
+func (s *SmartContract) ReactivateFisher(ctx contractapi.TransactionContextInterface, fisherId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can reactivate fishers")
+	}
+	return setFisherActive(ctx, fisherId, true)
+}
+
+// setFisherActive updates the Active flag on a fisher's private record
+func setFisherActive(ctx contractapi.TransactionContextInterface, fisherId string, active bool) error {
+	fisher, err := readFisher(ctx, fisherId)
+	if err != nil {
+		return err
+	}
+	fisher.Active = active
+
+	return putFisher(ctx, fisher)
+}
+
+// DeleteFisher removes an erroneously registered fisher; fishers with logged catches must be deactivated instead
+func (s *SmartContract) DeleteFisher(ctx contractapi.TransactionContextInterface, fisherId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can delete fishers")
+	}
+
+	fisher, err := readFisher(ctx, fisherId)
+	if err != nil {
+		return err
+	}
+	if err := withGovtID(ctx, fisher); err != nil {
+		return err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(fisherCatchIndex, []string{fisherId})
+	if err != nil {
+		return fmt.Errorf("failed to get catches for fisher %s: %v", fisherId, err)
+	}
+	hasCatches := resultsIterator.HasNext()
+	resultsIterator.Close()
+	if hasCatches {
+		return fmt.Errorf("cannot delete fisher with existing catches")
+	}
+
+	err = ctx.GetStub().DelPrivateData("FisherCollection", "FISHER_"+fisherId)
+	if err != nil {
+		return fmt.Errorf("failed to delete fisher %s: %v", fisherId, err)
+	}
+
+	if fisher.GovtID == "" {
+		return nil
+	}
+	err = ctx.GetStub().DelPrivateData("FisherPIICollection", "FISHER_"+fisherId)
+	if err != nil {
+		return fmt.Errorf("failed to delete PII for fisher %s: %v", fisherId, err)
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(govtIDFisherIndex, []string{fisher.GovtID, fisherId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	return ctx.GetStub().DelPrivateData("FisherPIICollection", indexKey)
+}
+
+// TransferFisherRegion moves a fisher to another jurisdiction, leaving the rest of the record untouched
+func (s *SmartContract) TransferFisherRegion(ctx contractapi.TransactionContextInterface, fisherId, newRegion string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can transfer fisher regions")
+	}
+
+	fisher, err := readFisher(ctx, fisherId)
+	if err != nil {
+		return err
+	}
+	fisher.Region = newRegion
+
+	if err := putFisher(ctx, fisher); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, EventFisherRegionTransferred, fisherId)
+}
+
+// GetFishersByRegion returns the fishers registered in a region; GovtID is only included for an authority
+func (s *SmartContract) GetFishersByRegion(ctx contractapi.TransactionContextInterface, region string) ([]*Fisher, error) {
+	return s.fishersMatching(ctx, func(fisher *Fisher) bool { return fisher.Region == region })
+}
+
+// GetAllFishers returns every registered fisher, including GovtID, for the authority directory
+func (s *SmartContract) GetAllFishers(ctx contractapi.TransactionContextInterface) ([]*Fisher, error) {
+	if !s.hasRole(ctx, "authority") {
+		return nil, unauthorized("only authority can list all fishers")
+	}
+
+	return s.fishersMatching(ctx, func(*Fisher) bool { return true })
+}
+
+// fishersMatching scans FisherCollection and keeps the fishers accepted by match;
+// GovtID is only included for an authority
+func (s *SmartContract) fishersMatching(ctx contractapi.TransactionContextInterface, match func(*Fisher) bool) ([]*Fisher, error) {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange("FisherCollection", "FISHER_", prefixEnd("FISHER_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fishers by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	isAuthority := s.hasRole(ctx, "authority")
+	fishers := []*Fisher{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var fisher Fisher
+		err = json.Unmarshal(queryResponse.Value, &fisher)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fisher data: %v", err)
+		}
+
+		if !match(&fisher) {
+			continue
+		}
+		if !isAuthority {
+			fisher.GovtID = ""
+		} else if err := withGovtID(ctx, &fisher); err != nil {
+			return nil, err
+		}
+		fishers = append(fishers, &fisher)
+	}
+
+	return fishers, nil
+}
+
+// govtIDFisherIndex maps government IDs to fisher IDs. GovtID is PII, so the
+// index is kept in FisherPIICollection rather than public state.
+const govtIDFisherIndex = "govtid~fisher"
+
+// putGovtIDIndex records the govtid~fisher index entry for a fisher; empty government IDs are not indexed
+func putGovtIDIndex(ctx contractapi.TransactionContextInterface, govtId, fisherId string) error {
+	if govtId == "" {
+		return nil
+	}
+	indexKey, err := ctx.GetStub().CreateCompositeKey(govtIDFisherIndex, []string{govtId, fisherId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData("FisherPIICollection", indexKey, indexValue)
+	if err != nil {
+		return fmt.Errorf("failed to index fisher %s: %v", fisherId, err)
+	}
+	return nil
+}
+
+// GetFisherByGovtID finds the fisher registered under a government ID; restricted to authority as it resolves PII
+func (s *SmartContract) GetFisherByGovtID(ctx contractapi.TransactionContextInterface, govtId string) (*Fisher, error) {
+	if !s.hasRole(ctx, "authority") {
+		return nil, unauthorized("only authority can look up fishers by govt ID")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey("FisherPIICollection", govtIDFisherIndex, []string{govtId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fisher for govt ID %s: %v", govtId, err)
+	}
+	defer resultsIterator.Close()
+
+	if !resultsIterator.HasNext() {
+		return nil, notFound("no fisher with govt ID %s", govtId)
+	}
+	queryResponse, err := resultsIterator.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed during results iteration: %v", err)
+	}
+	_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split composite key: %v", err)
+	}
+
+	fisher, err := readFisher(ctx, keyParts[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := withGovtID(ctx, fisher); err != nil {
+		return nil, err
+	}
+	return fisher, nil
+}
+
+// putFisher writes a fisher's basic record to FisherCollection and, when set, its
+// GovtID to the more restricted FisherPIICollection
+func putFisher(ctx contractapi.TransactionContextInterface, fisher *Fisher) error {
+	basic := *fisher
+	basic.GovtID = ""
+	fisherBytes, err := json.Marshal(basic)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fisher: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData("FisherCollection", "FISHER_"+fisher.ID, fisherBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put fisher %s: %v", fisher.ID, err)
+	}
+
+	if fisher.GovtID == "" {
+		return nil
+	}
+	piiBytes, err := json.Marshal(FisherPII{ID: fisher.ID, GovtID: fisher.GovtID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal fisher PII: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData("FisherPIICollection", "FISHER_"+fisher.ID, piiBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put PII for fisher %s: %v", fisher.ID, err)
+	}
+	return nil
+}
+
+// withGovtID fills in a fisher's GovtID from FisherPIICollection. Records written before
+// the split keep the GovtID stored alongside their basic fields.
+func withGovtID(ctx contractapi.TransactionContextInterface, fisher *Fisher) error {
+	piiBytes, err := ctx.GetStub().GetPrivateData("FisherPIICollection", "FISHER_"+fisher.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read PII for fisher %s: %v", fisher.ID, err)
+	}
+	if piiBytes == nil {
+		return nil
+	}
+
+	var pii FisherPII
+	if err := json.Unmarshal(piiBytes, &pii); err != nil {
+		return fmt.Errorf("failed to unmarshal fisher PII: %v", err)
+	}
+	fisher.GovtID = pii.GovtID
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// requestIndex keys processed client request IDs by the transaction function that handled them,
+// so an ID reused across functions is never mistaken for a retry
+const requestIndex = "req~fn~id"
+
+// processedRequest is what is stored for a client request ID once its transaction has run
+type processedRequest struct {
+	RecordID string `json:"recordId"`
+	TxID     string `json:"txId"`
+}
+
+// requestSeen reports whether fn has already processed a client request ID for recordId.
+// An empty ID opts out of idempotency and is never treated as seen; a replay naming a
+// different record than the original request is an error rather than a silent success.
+func requestSeen(ctx contractapi.TransactionContextInterface, fn, clientRequestId, recordId string) (bool, error) {
+	if clientRequestId == "" {
+		return false, nil
+	}
+	if err := requireMaxLength("clientRequestId", clientRequestId, maxIDLength); err != nil {
+		return false, err
+	}
+	requestKey, err := ctx.GetStub().CreateCompositeKey(requestIndex, []string{fn, clientRequestId})
+	if err != nil {
+		return false, fmt.Errorf("failed to create composite key: %v", err)
+	}
+	requestBytes, err := ctx.GetStub().GetState(requestKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read request %s: %v", clientRequestId, err)
+	}
+	if requestBytes == nil {
+		return false, nil
+	}
+
+	var request processedRequest
+	if err := json.Unmarshal(requestBytes, &request); err != nil {
+		return false, fmt.Errorf("failed to unmarshal request data: %v", err)
+	}
+	if request.RecordID != recordId {
+		return false, fmt.Errorf("request %s was already used by %s for %s, not %s", clientRequestId, fn, request.RecordID, recordId)
+	}
+	return true, nil
+}
+
+// recordRequest marks a client request ID as processed by fn for recordId, storing the transaction that handled it
+func recordRequest(ctx contractapi.TransactionContextInterface, fn, clientRequestId, recordId string) error {
+	if clientRequestId == "" {
+		return nil
+	}
+	requestKey, err := ctx.GetStub().CreateCompositeKey(requestIndex, []string{fn, clientRequestId})
+	if err != nil {
+		return fmt.Errorf("failed to create composite key: %v", err)
+	}
+	requestBytes, err := json.Marshal(processedRequest{RecordID: recordId, TxID: ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %v", err)
+	}
+	if err := ctx.GetStub().PutState(requestKey, requestBytes); err != nil {
+		return fmt.Errorf("failed to record request %s: %v", clientRequestId, err)
+	}
+	return nil
+}
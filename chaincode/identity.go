@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CallerInfo is the identity the chaincode sees for the submitting client
+type CallerInfo struct {
+	ID    string `json:"id"`
+	MSPID string `json:"mspId"`
+	Role  string `json:"role"`
+}
+
+// WhoAmI reports the caller's ID, MSP and resolved role to help debug certificate attributes
+func (s *SmartContract) WhoAmI(ctx contractapi.TransactionContextInterface) (string, error) {
+	id, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("failed to read caller ID: %v", err)
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("failed to read caller MSP ID: %v", err)
+	}
+	role, _ := resolveRole(ctx)
+
+	infoBytes, err := json.Marshal(CallerInfo{ID: id, MSPID: mspID, Role: role})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal caller info: %v", err)
+	}
+
+	return string(infoBytes), nil
+}
+
+// SetMSPPolicy adds an MSP to the allowlist for an action; once an action has a list, hasRole
+// grants that role by MSP membership instead of identity attributes
+func (s *SmartContract) SetMSPPolicy(ctx contractapi.TransactionContextInterface, action, mspId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set MSP policies")
+	}
+	if err := requireNonEmpty("mspId", mspId); err != nil {
+		return err
+	}
+
+	allowed, err := mspPolicy(ctx, action)
+	if err != nil {
+		return err
+	}
+	for _, existing := range allowed {
+		if existing == mspId {
+			return nil
+		}
+	}
+	allowed = append(allowed, mspId)
+
+	policyBytes, err := json.Marshal(allowed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal MSP policy: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CFG_MSP_POLICY_"+action, policyBytes)
+}
+
+// mspPolicy returns the MSP allowlist for an action, or nil when none is set
+func mspPolicy(ctx contractapi.TransactionContextInterface, action string) ([]string, error) {
+	policyBytes, err := ctx.GetStub().GetState("CFG_MSP_POLICY_" + action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MSP policy for %s: %v", action, err)
+	}
+	if policyBytes == nil {
+		return nil, nil
+	}
+
+	var allowed []string
+	if err := json.Unmarshal(policyBytes, &allowed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal MSP policy: %v", err)
+	}
+	return allowed, nil
+}
+
+// mspPolicyAllows reports whether the caller's MSP is on the action's allowlist; found is false
+// when no policy is set. An unreadable policy or MSP ID denies rather than falling back.
+func mspPolicyAllows(ctx contractapi.TransactionContextInterface, action string) (allowed bool, found bool) {
+	allowedMSPs, err := mspPolicy(ctx, action)
+	if err != nil {
+		return false, true
+	}
+	if allowedMSPs == nil {
+		return false, false
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return false, true
+	}
+	for _, msp := range allowedMSPs {
+		if msp == mspID {
+			return true, true
+		}
+	}
+	return false, true
+}
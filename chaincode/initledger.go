@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// initDoneKey marks that InitLedger has already seeded the ledger
+const initDoneKey = "INIT_DONE"
+
+// InitLedger seeds sample fishers, licenses, species, catches, a batch and an order for demos.
+// It is a no-op once the ledger has been initialised.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+	done, err := ctx.GetStub().GetState(initDoneKey)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", initDoneKey, err)
+	}
+	if done != nil {
+		return nil
+	}
+
+	recordedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	fishers := []Fisher{
+		{ID: "F001", Name: "Okello James", GovtID: "CM900112345", Role: "fisher", Active: true},
+		{ID: "F002", Name: "Nakato Grace", GovtID: "CF880154321", Role: "fisher", Active: true},
+	}
+	for i := range fishers {
+		if err := putFisher(ctx, &fishers[i]); err != nil {
+			return err
+		}
+		if err := putGovtIDIndex(ctx, fishers[i].GovtID, fishers[i].ID); err != nil {
+			return err
+		}
+	}
+
+	licenses := []License{
+		{LicenseID: "L001", FisherID: "F001", IssuedDate: "2025-01-01", ExpiryDate: "2025-12-31", Active: true},
+		{LicenseID: "L002", FisherID: "F002", IssuedDate: "2025-01-01", ExpiryDate: "2025-12-31", Active: true},
+	}
+	for _, license := range licenses {
+		licenseBytes, err := json.Marshal(license)
+		if err != nil {
+			return fmt.Errorf("failed to marshal license data: %v", err)
+		}
+		if err := ctx.GetStub().PutState("LICENSE_"+license.LicenseID, licenseBytes); err != nil {
+			return fmt.Errorf("failed to put license %s: %v", license.LicenseID, err)
+		}
+	}
+
+	species := []Species{
+		{Code: "Tilapia", CommonName: "Nile tilapia", ScientificName: "Oreochromis niloticus"},
+		{Code: "Nile Perch", CommonName: "Nile perch", ScientificName: "Lates niloticus"},
+	}
+	for _, sp := range species {
+		speciesBytes, err := json.Marshal(sp)
+		if err != nil {
+			return fmt.Errorf("failed to marshal species data: %v", err)
+		}
+		if err := ctx.GetStub().PutState("SPECIES_"+sp.Code, speciesBytes); err != nil {
+			return fmt.Errorf("failed to put species %s: %v", sp.Code, err)
+		}
+	}
+
+	catches := []Catch{
+		{CatchID: "C001", FisherID: "F001", SeqNo: 1, Species: "Tilapia", WeightKg: 12.5, Date: "2025-08-09", Latitude: -0.35, Longitude: 32.6, Grade: "A", StorageTempC: 2, RecordedAt: recordedAt},
+		{CatchID: "C002", FisherID: "F001", SeqNo: 2, Species: "Nile Perch", WeightKg: 30, Date: "2025-08-09", Latitude: -0.35, Longitude: 32.6, Grade: "A", StorageTempC: 2, RecordedAt: recordedAt},
+		{CatchID: "C003", FisherID: "F002", SeqNo: 1, Species: "Tilapia", WeightKg: 8.25, Date: "2025-08-10", Latitude: -0.42, Longitude: 33.2, Grade: "B", StorageTempC: 4, RecordedAt: recordedAt},
+	}
+	for i := range catches {
+		if err := putCatch(ctx, &catches[i]); err != nil {
+			return err
+		}
+	}
+	if err := addSpeciesCounts(ctx, catches); err != nil {
+		return err
+	}
+	if err := setCatchSeq(ctx, "F001", 2); err != nil {
+		return err
+	}
+	if err := setCatchSeq(ctx, "F002", 1); err != nil {
+		return err
+	}
+
+	qrCodeURL, err := batchQRCodeURL(ctx, "B001")
+	if err != nil {
+		return err
+	}
+	batch := Batch{
+		BatchID:     "B001",
+		CatchIDs:    []string{"C001", "C003"},
+		ProcessorID: "P001",
+		Date:        "2025-08-11",
+		QRCodeURL:   qrCodeURL,
+		BestBefore:  "2025-08-18",
+		RecordedAt:  recordedAt,
+	}
+	if err := assignLotNumber(ctx, &batch); err != nil {
+		return err
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+	if err := ctx.GetStub().PutState("BATCH_"+batch.BatchID, batchBytes); err != nil {
+		return fmt.Errorf("failed to put batch %s: %v", batch.BatchID, err)
+	}
+	for _, catchId := range batch.CatchIDs {
+		if err := moveCatchBatch(ctx, catchId, "", batch.BatchID); err != nil {
+			return err
+		}
+	}
+
+	order := Order{OrderID: "O001", BatchID: "B001", BatchIDs: []string{"B001"}, BuyerID: "BUY001", Status: "placed", Date: "2025-08-12", RecordedAt: recordedAt}
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	if err := ctx.GetStub().PutState("ORDER_"+order.OrderID, orderBytes); err != nil {
+		return fmt.Errorf("failed to put order %s: %v", order.OrderID, err)
+	}
+
+	return ctx.GetStub().PutState(initDoneKey, []byte(recordedAt))
+}
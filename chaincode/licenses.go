@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// IssueLicense allows an authority to issue a fishing license valid between two dates
+func (s *SmartContract) IssueLicense(ctx contractapi.TransactionContextInterface, licenseId, fisherId, issuedDate, expiryDate string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can issue licenses")
+	}
+
+	if err := requireNonEmpty("licenseId", licenseId); err != nil {
+		return err
+	}
+	if err := validateDate(issuedDate); err != nil {
+		return err
+	}
+	if err := validateDate(expiryDate); err != nil {
+		return err
+	}
+	if expiryDate < issuedDate {
+		return fmt.Errorf("license expiry %s is before issue date %s", expiryDate, issuedDate)
+	}
+
+	existing, err := ctx.GetStub().GetState("LICENSE_" + licenseId)
+	if err != nil {
+		return fmt.Errorf("failed to read license %s: %v", licenseId, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("license %s already exists", licenseId)
+	}
+	if _, err := readFisher(ctx, fisherId); err != nil {
+		return err
+	}
+
+	license := License{
+		LicenseID:  licenseId,
+		FisherID:   fisherId,
+		IssuedDate: issuedDate,
+		ExpiryDate: expiryDate,
+		Active:     true,
+	}
+
+	licenseBytes, err := json.Marshal(license)
+	if err != nil {
+		return fmt.Errorf("failed to marshal license data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("LICENSE_"+licenseId, licenseBytes)
+}
+
+// GetLicense retrieves a license by ID
+func (s *SmartContract) GetLicense(ctx contractapi.TransactionContextInterface, licenseId string) (*License, error) {
+	licenseBytes, err := ctx.GetStub().GetState("LICENSE_" + licenseId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license %s: %v", licenseId, err)
+	}
+	if licenseBytes == nil {
+		return nil, notFound("license %s not found", licenseId)
+	}
+
+	var license License
+	err = json.Unmarshal(licenseBytes, &license)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal license data: %v", err)
+	}
+
+	return &license, nil
+}
+
+// RevokeLicense allows an authority to deactivate a license before its expiry
+func (s *SmartContract) RevokeLicense(ctx contractapi.TransactionContextInterface, licenseId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can revoke licenses")
+	}
+
+	license, err := s.GetLicense(ctx, licenseId)
+	if err != nil {
+		return err
+	}
+	license.Active = false
+
+	licenseBytes, err := json.Marshal(license)
+	if err != nil {
+		return fmt.Errorf("failed to marshal license data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("LICENSE_"+licenseId, licenseBytes)
+}
+
+// hasValidLicense reports whether the fisher holds an active license covering the given date
+func hasValidLicense(ctx contractapi.TransactionContextInterface, fisherId, date string) (bool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("LICENSE_", prefixEnd("LICENSE_"))
+	if err != nil {
+		return false, fmt.Errorf("failed to get licenses by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return false, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var license License
+		err = json.Unmarshal(queryResponse.Value, &license)
+		if err != nil {
+			return false, fmt.Errorf("failed to unmarshal license data: %v", err)
+		}
+
+		if license.FisherID == fisherId && license.Active && license.IssuedDate <= date && license.ExpiryDate >= date {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
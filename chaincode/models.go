@@ -1,36 +1,158 @@
 package main
 
+import "encoding/json"
+
 // Fisher represents a registered fisher
 type Fisher struct {
 	ID     string `json:"id"`
 	Name   string `json:"name"`
-	GovtID string `json:"govtId"`
+	GovtID string `json:"govtId,omitempty"`
 	Role   string `json:"role"` // e.g., "fisher"
+	Region string `json:"region,omitempty"`
+	MSPID  string `json:"mspId,omitempty"`
+	Active bool   `json:"active"`
+}
+
+// UnmarshalJSON defaults Active to true so fishers registered before deactivation existed stay active
+func (f *Fisher) UnmarshalJSON(data []byte) error {
+	type fisherAlias Fisher
+	alias := fisherAlias{Active: true}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*f = Fisher(alias)
+	return nil
+}
+
+// FisherPII holds a fisher's personally identifying fields, kept in FisherPIICollection
+type FisherPII struct {
+	ID     string `json:"id"`
+	GovtID string `json:"govtId"`
 }
 
 // Catch represents a fishing catch log
 type Catch struct {
-	CatchID  string  `json:"catchId"`
-	FisherID string  `json:"fisherId"`
-	Species  string  `json:"species"`
-	WeightKg float64 `json:"weightKg"`
-	Date     string  `json:"date"`
+	CatchID        string  `json:"catchId"`
+	FisherID       string  `json:"fisherId"`
+	SeqNo          int     `json:"seqNo,omitempty"`
+	ReassignedFrom string  `json:"reassignedFrom,omitempty"`
+	MSPID          string  `json:"mspId,omitempty"`
+	VesselID       string  `json:"vesselId,omitempty"`
+	Species        string  `json:"species"`
+	WeightKg       float64 `json:"weightKg"`
+	Date           string  `json:"date"`
+	Latitude       float64 `json:"lat"`
+	Longitude      float64 `json:"lng"`
+	Grade          string  `json:"grade,omitempty"`
+	StorageTempC   float64 `json:"storageTempC"`
+	RecordedAt     string  `json:"recordedAt"`
+	Verified       bool    `json:"verified"`
+	VerifiedBy     string  `json:"verifiedBy,omitempty"`
+	VerifiedAt     string  `json:"verifiedAt,omitempty"`
+	Condemned      bool    `json:"condemned,omitempty"`
+	CondemnReason  string  `json:"condemnReason,omitempty"`
+	Archived       bool    `json:"archived,omitempty"`
 }
 
 // Batch represents a processed batch of catches
 type Batch struct {
-	BatchID     string   `json:"batchId"`
-	CatchIDs    []string `json:"catchIds"`
-	ProcessorID string   `json:"processorId"`
-	Date        string   `json:"date"`
-	QRCodeURL   string   `json:"qrCodeUrl"`
+	BatchID          string        `json:"batchId"`
+	CatchIDs         []string      `json:"catchIds"`
+	ProcessorID      string        `json:"processorId"`
+	LotNumber        string        `json:"lotNumber,omitempty"`
+	Date             string        `json:"date"`
+	QRCodeURL        string        `json:"qrCodeUrl"`
+	ParentBatchID    string        `json:"parentBatchId,omitempty"`
+	MergedFrom       []string      `json:"mergedFrom,omitempty"`
+	BestBefore       string        `json:"bestBefore,omitempty"`
+	DeclaredWeightKg float64       `json:"declaredWeightKg,omitempty"`
+	Certificates     []Certificate `json:"certificates,omitempty"`
+	OnHold           bool          `json:"onHold,omitempty"`
+	HoldReason       string        `json:"holdReason,omitempty"`
+	RecordedAt       string        `json:"recordedAt"`
+	ReceivedAt       string        `json:"receivedAt,omitempty"`
+	ProcessedAt      string        `json:"processedAt,omitempty"`
+	ShippedAt        string        `json:"shippedAt,omitempty"`
+	Archived         bool          `json:"archived,omitempty"`
 }
 
 // Order represents a buyer order
 type Order struct {
-	OrderID string `json:"orderId"`
-	BatchID string `json:"batchId"`
-	BuyerID string `json:"buyerId"`
-	Status  string `json:"status"` // e.g., "placed", "shipped"
-	Date    string `json:"date"`
+	OrderID         string             `json:"orderId"`
+	BatchID         string             `json:"batchId"`
+	BatchIDs        []string           `json:"batchIds"`
+	BuyerID         string             `json:"buyerId"`
+	Status          string             `json:"status"` // e.g., "placed", "shipped"
+	Date            string             `json:"date"`
+	OrderQuantityKg float64            `json:"orderQuantityKg,omitempty"`
+	FulfilledKg     float64            `json:"fulfilledKg,omitempty"`
+	AllocatedKg     map[string]float64 `json:"allocatedKg,omitempty"` // batch ID -> kg of that batch claimed by this order
+	Amount          float64            `json:"amount,omitempty"`
+	Currency        string             `json:"currency,omitempty"`
+	RecordedAt      string             `json:"recordedAt"`
+	Archived        bool               `json:"archived,omitempty"`
+}
+
+// UnmarshalJSON migrates orders stored before multi-batch fulfilment, which only carry BatchID
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type orderAlias Order
+	var alias orderAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	if len(alias.BatchIDs) == 0 && alias.BatchID != "" {
+		alias.BatchIDs = []string{alias.BatchID}
+	}
+	*o = Order(alias)
+	return nil
+}
+
+// Vessel represents a registered fishing vessel
+type Vessel struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	RegistrationNo string `json:"registrationNo"`
+	OwnerFisherID  string `json:"ownerFisherId"`
+}
+
+// License represents a fishing license issued to a fisher
+type License struct {
+	LicenseID  string `json:"licenseId"`
+	FisherID   string `json:"fisherId"`
+	IssuedDate string `json:"issuedDate"`
+	ExpiryDate string `json:"expiryDate"`
+	Active     bool   `json:"active"`
+}
+
+// Certificate references an export document, such as a health or catch certificate, attached to a batch
+type Certificate struct {
+	Type       string `json:"type"`
+	Reference  string `json:"reference"`
+	IssuedBy   string `json:"issuedBy"`
+	IssuedDate string `json:"issuedDate"`
+}
+
+// PriceQuote is a processor's confidential price for a batch, kept in PriceCollection
+type PriceQuote struct {
+	BatchID     string  `json:"batchId"`
+	ProcessorID string  `json:"processorId"`
+	Price       float64 `json:"price"`
+	SubmittedAt string  `json:"submittedAt"`
+}
+
+// Species is a registry entry; catches record the Code as their species
+type Species struct {
+	Code           string `json:"code"`
+	CommonName     string `json:"commonName"`
+	ScientificName string `json:"scientificName"`
+}
+
+// Shipment tracks the physical delivery of an order
+type Shipment struct {
+	ShipmentID string `json:"shipmentId"`
+	OrderID    string `json:"orderId"`
+	Carrier    string `json:"carrier"`
+	TrackingNo string `json:"trackingNo"`
+	Status     string `json:"status"`
+	Date       string `json:"date"`
 }
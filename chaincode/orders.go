@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// orderTransitions lists the statuses an order may move to from each status
+var orderTransitions = map[string][]string{
+	"placed":    {"confirmed", "cancelled"},
+	"fulfilled": {"confirmed", "cancelled"},
+	"confirmed": {"shipped", "cancelled"},
+	"shipped":   {"delivered"},
+}
+
+// orderStatusRoles maps a target status to the role allowed to set it
+var orderStatusRoles = map[string]string{
+	"confirmed": "processor",
+	"shipped":   "processor",
+	"delivered": "buyer",
+	"cancelled": "buyer",
+}
+
+// UpdateOrderStatus advances an order along the allowed status transitions
+func (s *SmartContract) UpdateOrderStatus(ctx contractapi.TransactionContextInterface, orderId, newStatus string) error {
+	role, ok := orderStatusRoles[newStatus]
+	if !ok {
+		return fmt.Errorf("unknown order status %q", newStatus)
+	}
+	if !s.hasRole(ctx, role) {
+		return unauthorized("only %s can set order status to %s", role, newStatus)
+	}
+
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if !canTransition(order.Status, newStatus) {
+		return fmt.Errorf("invalid order status transition from %s to %s", order.Status, newStatus)
+	}
+	if newStatus == "confirmed" || newStatus == "shipped" {
+		if err := checkNotHeld(ctx, order); err != nil {
+			return err
+		}
+	}
+	order.Status = newStatus
+
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+
+	// Shipping without a shipment record must still stamp the batches, as CreateShipment does
+	if newStatus == "shipped" {
+		for _, batchId := range order.BatchIDs {
+			if err := s.markBatchShipped(ctx, batchId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CancelOrder cancels an order that has not shipped; allowed for the ordering buyer or an authority
+func (s *SmartContract) CancelOrder(ctx contractapi.TransactionContextInterface, orderId string) error {
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if !s.hasRole(ctx, "authority") && !s.isCaller(ctx, order.BuyerID) {
+		return unauthorized("only the buyer or an authority can cancel an order")
+	}
+	if !canTransition(order.Status, "cancelled") {
+		return fmt.Errorf("cannot cancel order %s with status %s", orderId, order.Status)
+	}
+	order.Status = "cancelled"
+
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+
+	return emitEvent(ctx, EventOrderCancelled, orderId)
+}
+
+// AmendOrder moves a still-placed order onto a single different batch; only the ordering buyer may amend
+func (s *SmartContract) AmendOrder(ctx contractapi.TransactionContextInterface, orderId, newBatchId string) error {
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if !s.isCaller(ctx, order.BuyerID) {
+		return unauthorized("only the buyer can amend an order")
+	}
+	if order.Status != "placed" && order.Status != "fulfilled" {
+		return fmt.Errorf("cannot amend order %s with status %s", orderId, order.Status)
+	}
+
+	batchBytes, err := ctx.GetStub().GetState("BATCH_" + newBatchId)
+	if err != nil {
+		return fmt.Errorf("failed to read batch %s: %v", newBatchId, err)
+	}
+	if batchBytes == nil {
+		return fmt.Errorf("cannot amend order: batch %s not found", newBatchId)
+	}
+	order.BatchID = newBatchId
+	order.BatchIDs = []string{newBatchId}
+	if order.OrderQuantityKg > 0 {
+		order.FulfilledKg = 0
+		order.AllocatedKg = nil
+		if err := s.allocateFromBatch(ctx, order, newBatchId); err != nil {
+			return err
+		}
+	}
+
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+	return nil
+}
+
+// FulfillFromBatch adds a batch towards a placed order's quantity; the order becomes "fulfilled"
+// once its batches' allocated weight reaches OrderQuantityKg
+func (s *SmartContract) FulfillFromBatch(ctx contractapi.TransactionContextInterface, orderId, batchId string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can fulfil orders")
+	}
+
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if order.Status != "placed" {
+		return fmt.Errorf("cannot fulfil order %s with status %s", orderId, order.Status)
+	}
+	if order.OrderQuantityKg == 0 {
+		return fmt.Errorf("order %s has no quantity to fulfil", orderId)
+	}
+	if order.hasBatch(batchId) {
+		return fmt.Errorf("batch %s already fulfils order %s", batchId, orderId)
+	}
+
+	if err := s.allocateFromBatch(ctx, order, batchId); err != nil {
+		return err
+	}
+	order.BatchIDs = append(order.BatchIDs, batchId)
+
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+	return nil
+}
+
+// allocateFromBatch claims as much of the batch's unallocated weight as the order still needs,
+// recording it under AllocatedKg; a batch already fully claimed by other live orders is rejected
+func (s *SmartContract) allocateFromBatch(ctx contractapi.TransactionContextInterface, order *Order, batchId string) error {
+	weightKg, err := s.batchWeightKg(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	claimed, err := ordersMatching(ctx, func(other *Order) bool {
+		return other.OrderID != order.OrderID && other.Status != "cancelled" && other.AllocatedKg[batchId] > 0
+	})
+	if err != nil {
+		return err
+	}
+	for _, other := range claimed {
+		weightKg -= other.AllocatedKg[batchId]
+	}
+	availableKg := roundWeightKg(weightKg)
+	if availableKg <= 0 {
+		return fmt.Errorf("batch %s is fully allocated to other orders", batchId)
+	}
+
+	takeKg := roundWeightKg(order.OrderQuantityKg - order.FulfilledKg)
+	if availableKg < takeKg {
+		takeKg = availableKg
+	}
+	if order.AllocatedKg == nil {
+		order.AllocatedKg = make(map[string]float64)
+	}
+	order.AllocatedKg[batchId] = takeKg
+	order.FulfilledKg = roundWeightKg(order.FulfilledKg + takeKg)
+	order.Status = fulfilmentStatus(order)
+	return nil
+}
+
+// fulfilmentStatus returns "fulfilled" once an order's batches cover its quantity, else "placed"
+func fulfilmentStatus(order *Order) string {
+	if order.FulfilledKg >= order.OrderQuantityKg {
+		return "fulfilled"
+	}
+	return "placed"
+}
+
+// hasBatch reports whether the batch is one of those fulfilling the order
+func (o *Order) hasBatch(batchId string) bool {
+	for _, id := range o.BatchIDs {
+		if id == batchId {
+			return true
+		}
+	}
+	return false
+}
+
+// canTransition reports whether an order may move from one status to another
+func canTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusEntry is one status an order held, with the transaction that set it
+type OrderStatusEntry struct {
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	Status    string `json:"status"`
+}
+
+// GetOrderStatusHistory returns the statuses an order has passed through, oldest first.
+// Requires the peer history database (ledger.history.enableHistoryDatabase in core.yaml).
+func (s *SmartContract) GetOrderStatusHistory(ctx contractapi.TransactionContextInterface, orderId string) (string, error) {
+	historyIterator, err := ctx.GetStub().GetHistoryForKey("ORDER_" + orderId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get history for order %s: %v", orderId, err)
+	}
+	defer historyIterator.Close()
+
+	type timedEntry struct {
+		at    time.Time
+		entry OrderStatusEntry
+	}
+	var entries []timedEntry
+	for historyIterator.HasNext() {
+		modification, err := historyIterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("failed during history iteration: %v", err)
+		}
+		if modification.IsDelete {
+			continue
+		}
+
+		var order Order
+		if err := json.Unmarshal(modification.Value, &order); err != nil {
+			return "", fmt.Errorf("failed to unmarshal order data: %v", err)
+		}
+		at := modification.Timestamp.AsTime().UTC()
+		entries = append(entries, timedEntry{at: at, entry: OrderStatusEntry{
+			TxID:      modification.TxId,
+			Timestamp: at.Format(time.RFC3339),
+			Status:    order.Status,
+		}})
+	}
+
+	// The history database doesn't guarantee an order, so sort on the full-precision timestamp
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	history := make([]OrderStatusEntry, 0, len(entries))
+	for _, e := range entries {
+		history = append(history, e.entry)
+	}
+
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal history data: %v", err)
+	}
+
+	return string(historyBytes), nil
+}
+
+// GetOrdersByBuyer returns every order placed by the given buyer; archived orders are only
+// included when includeArchived is set
+func (s *SmartContract) GetOrdersByBuyer(ctx contractapi.TransactionContextInterface, buyerId string, includeArchived bool) ([]*Order, error) {
+	return ordersMatching(ctx, func(order *Order) bool {
+		return (includeArchived || !order.Archived) && order.BuyerID == buyerId
+	})
+}
+
+// GetOrdersByBatch returns every order placed against the given batch; archived orders are only
+// included when includeArchived is set
+func (s *SmartContract) GetOrdersByBatch(ctx contractapi.TransactionContextInterface, batchId string, includeArchived bool) ([]*Order, error) {
+	return ordersMatching(ctx, func(order *Order) bool {
+		return (includeArchived || !order.Archived) && order.hasBatch(batchId)
+	})
+}
+
+// checkOpenOrderLimit rejects a new order when the buyer already holds the maximum number of open orders
+func (s *SmartContract) checkOpenOrderLimit(ctx contractapi.TransactionContextInterface, buyerId string) error {
+	max, err := maxOpenOrders(ctx)
+	if err != nil || max == 0 {
+		return err
+	}
+
+	orders, err := s.GetOrdersByBuyer(ctx, buyerId, true)
+	if err != nil {
+		return err
+	}
+	open := 0
+	for _, order := range orders {
+		if order.Status != "delivered" && order.Status != "cancelled" {
+			open++
+		}
+	}
+	if open >= max {
+		return fmt.Errorf("open order limit reached")
+	}
+	return nil
+}
+
+// GetActiveOrdersCount returns the number of orders that can still move to another status,
+// i.e. placed, fulfilled, confirmed or shipped
+func (s *SmartContract) GetActiveOrdersCount(ctx contractapi.TransactionContextInterface) (int, error) {
+	orders, err := ordersMatching(ctx, func(order *Order) bool {
+		_, active := orderTransitions[order.Status]
+		return active
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(orders), nil
+}
+
+// ordersMatching scans all orders and keeps those accepted by match
+func ordersMatching(ctx contractapi.TransactionContextInterface, match func(*Order) bool) ([]*Order, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("ORDER_", prefixEnd("ORDER_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	orders := []*Order{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var order Order
+		err = json.Unmarshal(queryResponse.Value, &order)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal order data: %v", err)
+		}
+
+		if match(&order) {
+			orders = append(orders, &order)
+		}
+	}
+
+	return orders, nil
+}
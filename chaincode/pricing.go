@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SubmitPriceQuote records the processor's price for a batch in the PriceCollection private data
+func (s *SmartContract) SubmitPriceQuote(ctx contractapi.TransactionContextInterface, batchId, priceStr string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can submit price quotes")
+	}
+
+	price, err := strconv.ParseFloat(priceStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid price value '%s': %v", priceStr, err)
+	}
+	if price <= 0 {
+		return fmt.Errorf("price must be positive")
+	}
+
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+
+	submittedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	quote := PriceQuote{
+		BatchID:     batchId,
+		ProcessorID: batch.ProcessorID,
+		Price:       price,
+		SubmittedAt: submittedAt,
+	}
+	quoteBytes, err := json.Marshal(quote)
+	if err != nil {
+		return fmt.Errorf("failed to marshal price quote: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData("PriceCollection", "PRICE_"+batchId, quoteBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put price quote for batch %s: %v", batchId, err)
+	}
+	return nil
+}
+
+// GetPriceQuote returns the price quote for a batch; only the trading parties may read it
+func (s *SmartContract) GetPriceQuote(ctx contractapi.TransactionContextInterface, batchId string) (*PriceQuote, error) {
+	if !s.hasRole(ctx, "processor") && !s.hasRole(ctx, "buyer") {
+		return nil, unauthorized("only processor or buyer can read price quotes")
+	}
+	return readPriceQuote(ctx, batchId)
+}
+
+// readPriceQuote loads a price quote without access checks
+func readPriceQuote(ctx contractapi.TransactionContextInterface, batchId string) (*PriceQuote, error) {
+	quoteBytes, err := ctx.GetStub().GetPrivateData("PriceCollection", "PRICE_"+batchId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price quote for batch %s: %v", batchId, err)
+	}
+	if quoteBytes == nil {
+		return nil, notFound("price quote for batch %s not found", batchId)
+	}
+
+	var quote PriceQuote
+	if err := json.Unmarshal(quoteBytes, &quote); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal price quote: %v", err)
+	}
+	return &quote, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SetSpeciesQuota caps the total catch weight that may be logged for a species
+func (s *SmartContract) SetSpeciesQuota(ctx contractapi.TransactionContextInterface, species, maxKgStr string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set species quotas")
+	}
+
+	maxKg, err := strconv.ParseFloat(maxKgStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid maxKg value '%s': %v", maxKgStr, err)
+	}
+	if maxKg < 0 {
+		return fmt.Errorf("quota must not be negative")
+	}
+
+	return ctx.GetStub().PutState("CFG_QUOTA_"+species, []byte(strconv.FormatFloat(maxKg, 'f', -1, 64)))
+}
+
+// checkSpeciesQuota rejects a new catch that would push the species total over its quota.
+// Species without a quota are unrestricted.
+func (s *SmartContract) checkSpeciesQuota(ctx contractapi.TransactionContextInterface, species string, weightKg float64) error {
+	quotaBytes, err := ctx.GetStub().GetState("CFG_QUOTA_" + species)
+	if err != nil {
+		return fmt.Errorf("failed to read quota for %s: %v", species, err)
+	}
+	if quotaBytes == nil {
+		return nil
+	}
+	quotaKg, err := strconv.ParseFloat(string(quotaBytes), 64)
+	if err != nil {
+		return fmt.Errorf("invalid quota stored for %s: %v", species, err)
+	}
+
+	totalKg, err := speciesTotalKg(ctx, species)
+	if err != nil {
+		return err
+	}
+	if roundWeightKg(totalKg+weightKg) > quotaKg {
+		return fmt.Errorf("species quota exceeded for %s", species)
+	}
+	return nil
+}
+
+// SetMinWeight sets the minimum landing weight for a species to protect juveniles
+func (s *SmartContract) SetMinWeight(ctx contractapi.TransactionContextInterface, species, minKgStr string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set minimum landing weights")
+	}
+
+	minKg, err := strconv.ParseFloat(minKgStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid minKg value '%s': %v", minKgStr, err)
+	}
+	if minKg <= 0 {
+		return fmt.Errorf("minimum weight must be positive")
+	}
+
+	return ctx.GetStub().PutState("CFG_MIN_WEIGHT_"+species, []byte(strconv.FormatFloat(minKg, 'f', -1, 64)))
+}
+
+// checkMinWeight rejects a catch lighter than its species' minimum landing weight.
+// Species without a minimum are unrestricted.
+func checkMinWeight(ctx contractapi.TransactionContextInterface, species string, weightKg float64) error {
+	minBytes, err := ctx.GetStub().GetState("CFG_MIN_WEIGHT_" + species)
+	if err != nil {
+		return fmt.Errorf("failed to read minimum weight for %s: %v", species, err)
+	}
+	if minBytes == nil {
+		return nil
+	}
+	minKg, err := strconv.ParseFloat(string(minBytes), 64)
+	if err != nil {
+		return fmt.Errorf("invalid minimum weight stored for %s: %v", species, err)
+	}
+	if weightKg < minKg {
+		return fmt.Errorf("catch below minimum landing weight for %s", species)
+	}
+	return nil
+}
+
+// dailyCatchLimitKey holds the most weight a fisher may log per calendar day; unset means unlimited
+const dailyCatchLimitKey = "CFG_DAILY_CATCH_LIMIT"
+
+// SetDailyCatchLimit caps the total weight a single fisher may log for any one day
+func (s *SmartContract) SetDailyCatchLimit(ctx contractapi.TransactionContextInterface, maxKgStr string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set the daily catch limit")
+	}
+
+	maxKg, err := strconv.ParseFloat(maxKgStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid maxKg value '%s': %v", maxKgStr, err)
+	}
+	if maxKg <= 0 {
+		return fmt.Errorf("daily catch limit must be positive")
+	}
+
+	return ctx.GetStub().PutState(dailyCatchLimitKey, []byte(strconv.FormatFloat(maxKg, 'f', -1, 64)))
+}
+
+// checkDailyCatchLimit rejects a catch that would take its fisher's total for the catch date over the daily limit.
+// pendingKg is weight for the same fisher and date not yet committed.
+func (s *SmartContract) checkDailyCatchLimit(ctx contractapi.TransactionContextInterface, catch *Catch, pendingKg float64) error {
+	limitBytes, err := ctx.GetStub().GetState(dailyCatchLimitKey)
+	if err != nil {
+		return fmt.Errorf("failed to read daily catch limit: %v", err)
+	}
+	if limitBytes == nil {
+		return nil
+	}
+	limitKg, err := strconv.ParseFloat(string(limitBytes), 64)
+	if err != nil {
+		return fmt.Errorf("invalid daily catch limit stored: %v", err)
+	}
+
+	catches, err := s.fisherCatches(ctx, catch.FisherID)
+	if err != nil {
+		return err
+	}
+	totalKg := catch.WeightKg + pendingKg
+	for _, logged := range catches {
+		if logged.Date == catch.Date {
+			totalKg += logged.WeightKg
+		}
+	}
+	if roundWeightKg(totalKg) > limitKg {
+		return fmt.Errorf("daily catch limit of %g kg exceeded for fisher %s on %s", limitKg, catch.FisherID, catch.Date)
+	}
+	return nil
+}
+
+// SetEndangeredSpecies lists a species as protected; LogCatch refuses protected species
+func (s *SmartContract) SetEndangeredSpecies(ctx contractapi.TransactionContextInterface, species string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can list endangered species")
+	}
+
+	listedAt, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState("CFG_ENDANGERED_"+species, []byte(listedAt))
+}
+
+// RemoveEndangeredSpecies lifts a protected listing
+func (s *SmartContract) RemoveEndangeredSpecies(ctx contractapi.TransactionContextInterface, species string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can delist endangered species")
+	}
+
+	listed, err := isEndangered(ctx, species)
+	if err != nil {
+		return err
+	}
+	if !listed {
+		return fmt.Errorf("species %s is not listed as endangered", species)
+	}
+
+	return ctx.GetStub().DelState("CFG_ENDANGERED_" + species)
+}
+
+// isEndangered reports whether a species is on the protected list
+func isEndangered(ctx contractapi.TransactionContextInterface, species string) (bool, error) {
+	listedBytes, err := ctx.GetStub().GetState("CFG_ENDANGERED_" + species)
+	if err != nil {
+		return false, fmt.Errorf("failed to read endangered listing for %s: %v", species, err)
+	}
+	return listedBytes != nil, nil
+}
+
+// SetRegionSpecies replaces the list of species that fishers in a region may log
+func (s *SmartContract) SetRegionSpecies(ctx contractapi.TransactionContextInterface, region string, speciesList []string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set region species")
+	}
+	if len(speciesList) == 0 {
+		return fmt.Errorf("species list must not be empty")
+	}
+
+	listBytes, err := json.Marshal(speciesList)
+	if err != nil {
+		return fmt.Errorf("failed to marshal species list: %v", err)
+	}
+
+	return ctx.GetStub().PutState("CFG_REGION_SPECIES_"+region, listBytes)
+}
+
+// checkRegionSpecies rejects a species not on the region's list.
+// Regions without a configured list allow any species.
+func checkRegionSpecies(ctx contractapi.TransactionContextInterface, region, species string) error {
+	listBytes, err := ctx.GetStub().GetState("CFG_REGION_SPECIES_" + region)
+	if err != nil {
+		return fmt.Errorf("failed to read species list for region %s: %v", region, err)
+	}
+	if listBytes == nil {
+		return nil
+	}
+
+	var allowed []string
+	if err := json.Unmarshal(listBytes, &allowed); err != nil {
+		return fmt.Errorf("failed to unmarshal species list: %v", err)
+	}
+	for _, permitted := range allowed {
+		if permitted == species {
+			return nil
+		}
+	}
+	return fmt.Errorf("species %s not permitted in region %s", species, region)
+}
+
+// defaultShelfLifeDays applies to species without a configured shelf life
+const defaultShelfLifeDays = 7
+
+// SetSpeciesShelfLife sets how many days a species stays fresh after batching
+func (s *SmartContract) SetSpeciesShelfLife(ctx contractapi.TransactionContextInterface, species, daysStr string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can set shelf lives")
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil {
+		return fmt.Errorf("invalid days value '%s': %v", daysStr, err)
+	}
+	if days <= 0 {
+		return fmt.Errorf("shelf life must be positive")
+	}
+
+	return ctx.GetStub().PutState("CFG_SHELF_LIFE_"+species, []byte(strconv.Itoa(days)))
+}
+
+// shelfLifeDays returns the configured shelf life for a species, or the default
+func shelfLifeDays(ctx contractapi.TransactionContextInterface, species string) (int, error) {
+	daysBytes, err := ctx.GetStub().GetState("CFG_SHELF_LIFE_" + species)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read shelf life for %s: %v", species, err)
+	}
+	if daysBytes == nil {
+		return defaultShelfLifeDays, nil
+	}
+	days, err := strconv.Atoi(string(daysBytes))
+	if err != nil {
+		return 0, fmt.Errorf("invalid shelf life stored for %s: %v", species, err)
+	}
+	return days, nil
+}
+
+// batchBestBefore computes a batch's best-before date from the most perishable species it contains
+func (s *SmartContract) batchBestBefore(ctx contractapi.TransactionContextInterface, date string, catchIds []string) (string, error) {
+	batchDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+
+	minDays := -1
+	for _, catchId := range catchIds {
+		catch, err := s.GetCatch(ctx, catchId)
+		if err != nil {
+			return "", err
+		}
+		days, err := shelfLifeDays(ctx, catch.Species)
+		if err != nil {
+			return "", err
+		}
+		if minDays < 0 || days < minDays {
+			minDays = days
+		}
+	}
+	if minDays < 0 {
+		minDays = defaultShelfLifeDays
+	}
+
+	return batchDate.AddDate(0, 0, minDays).Format("2006-01-02"), nil
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ReportPage is one page of a paginated catch report
+type ReportPage struct {
+	Catches      []Catch `json:"catches"`
+	Bookmark     string  `json:"bookmark"`
+	FetchedCount int32   `json:"fetchedCount"`
+}
+
+// GenerateReportPaged returns one page of catches between dates along with the bookmark
+// for the next page; FetchedCount is the number of records scanned, before date filtering.
+// Archived catches are only included when includeArchived is set.
+func (s *SmartContract) GenerateReportPaged(ctx contractapi.TransactionContextInterface, startDate, endDate string, pageSize int, bookmark string, includeArchived bool) (*ReportPage, error) {
+	if !s.hasRole(ctx, "authority") {
+		return nil, unauthorized("only authority can generate reports")
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("pageSize must be positive")
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("CATCH_", prefixEnd("CATCH_"), int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &ReportPage{Catches: []Catch{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+
+		if catch.Archived && !includeArchived {
+			continue
+		}
+		if catch.Date >= startDate && catch.Date <= endDate {
+			page.Catches = append(page.Catches, catch)
+		}
+	}
+
+	page.Bookmark = metadata.Bookmark
+	page.FetchedCount = metadata.FetchedRecordsCount
+
+	return page, nil
+}
+
+// GenerateReportCSV returns the catches GenerateReport would, as CSV with a header row
+func (s *SmartContract) GenerateReportCSV(ctx contractapi.TransactionContextInterface, startDate, endDate string) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can generate reports")
+	}
+
+	catches, err := catchesInRange(ctx, startDate, endDate, false)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	if err := writer.Write([]string{"catchId", "fisherId", "species", "weightKg", "date"}); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	for _, catch := range catches {
+		record := []string{catch.CatchID, catch.FisherID, catch.Species, strconv.FormatFloat(catch.WeightKg, 'f', -1, 64), catch.Date}
+		if err := writer.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV record: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+
+	return out.String(), nil
+}
+
+// SpeciesStats aggregates catches of a single species
+type SpeciesStats struct {
+	Count    int     `json:"count"`
+	WeightKg float64 `json:"weightKg"`
+}
+
+// CatchStats aggregates catches over a date range
+type CatchStats struct {
+	TotalCatches    int                      `json:"totalCatches"`
+	VerifiedCatches int                      `json:"verifiedCatches"`
+	TotalWeightKg   float64                  `json:"totalWeightKg"`
+	BySpecies       map[string]*SpeciesStats `json:"bySpecies"`
+}
+
+// GetCatchStats returns catch totals between dates, grouped by species
+func (s *SmartContract) GetCatchStats(ctx contractapi.TransactionContextInterface, startDate, endDate string) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can view catch statistics")
+	}
+
+	catches, err := catchesInRange(ctx, startDate, endDate, false)
+	if err != nil {
+		return "", err
+	}
+
+	stats := CatchStats{BySpecies: map[string]*SpeciesStats{}}
+	for _, catch := range catches {
+		stats.TotalCatches++
+		if catch.Verified {
+			stats.VerifiedCatches++
+		}
+		stats.TotalWeightKg += catch.WeightKg
+
+		species, ok := stats.BySpecies[catch.Species]
+		if !ok {
+			species = &SpeciesStats{}
+			stats.BySpecies[catch.Species] = species
+		}
+		species.Count++
+		species.WeightKg += catch.WeightKg
+	}
+	// Re-round the sums so adding gram-precision weights doesn't reintroduce float noise
+	stats.TotalWeightKg = roundWeightKg(stats.TotalWeightKg)
+	for _, species := range stats.BySpecies {
+		species.WeightKg = roundWeightKg(species.WeightKg)
+	}
+
+	statsBytes, err := json.Marshal(stats)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal catch statistics: %v", err)
+	}
+
+	return string(statsBytes), nil
+}
+
+// FisherCatchSummary aggregates every catch logged by one fisher
+type FisherCatchSummary struct {
+	FisherID       string                   `json:"fisherId"`
+	TotalCatches   int                      `json:"totalCatches"`
+	TotalWeightKg  float64                  `json:"totalWeightKg"`
+	BySpecies      map[string]*SpeciesStats `json:"bySpecies"`
+	FirstCatchDate string                   `json:"firstCatchDate"`
+	LastCatchDate  string                   `json:"lastCatchDate"`
+}
+
+// GetFisherCatchSummary returns a fisher's catch totals, resolved through the fisher~catch index
+func (s *SmartContract) GetFisherCatchSummary(ctx contractapi.TransactionContextInterface, fisherId string) (string, error) {
+	if !s.hasRole(ctx, "authority") && !s.isCaller(ctx, fisherId) {
+		return "", unauthorized("only the fisher or an authority can view a catch summary")
+	}
+
+	catches, err := s.fisherCatches(ctx, fisherId)
+	if err != nil {
+		return "", err
+	}
+
+	summary := FisherCatchSummary{FisherID: fisherId, BySpecies: map[string]*SpeciesStats{}}
+	for _, catch := range catches {
+		summary.TotalCatches++
+		summary.TotalWeightKg += catch.WeightKg
+
+		species, ok := summary.BySpecies[catch.Species]
+		if !ok {
+			species = &SpeciesStats{}
+			summary.BySpecies[catch.Species] = species
+		}
+		species.Count++
+		species.WeightKg += catch.WeightKg
+
+		if summary.FirstCatchDate == "" || catch.Date < summary.FirstCatchDate {
+			summary.FirstCatchDate = catch.Date
+		}
+		if catch.Date > summary.LastCatchDate {
+			summary.LastCatchDate = catch.Date
+		}
+	}
+	summary.TotalWeightKg = roundWeightKg(summary.TotalWeightKg)
+	for _, species := range summary.BySpecies {
+		species.WeightKg = roundWeightKg(species.WeightKg)
+	}
+
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal catch summary: %v", err)
+	}
+
+	return string(summaryBytes), nil
+}
+
+// FisherStats aggregates one fisher's catches within a report window
+type FisherStats struct {
+	Count         int     `json:"count"`
+	TotalWeightKg float64 `json:"totalWeightKg"`
+}
+
+// GenerateReportByFisher returns unarchived catches between dates grouped by fisher, as a JSON
+// object mapping fisher ID to count and total weight
+func (s *SmartContract) GenerateReportByFisher(ctx contractapi.TransactionContextInterface, startDate, endDate string) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can generate reports")
+	}
+
+	catches, err := catchesInRange(ctx, startDate, endDate, false)
+	if err != nil {
+		return "", err
+	}
+
+	report := map[string]*FisherStats{}
+	for _, catch := range catches {
+		stats, ok := report[catch.FisherID]
+		if !ok {
+			stats = &FisherStats{}
+			report[catch.FisherID] = stats
+		}
+		stats.Count++
+		stats.TotalWeightKg += catch.WeightKg
+	}
+	for _, stats := range report {
+		stats.TotalWeightKg = roundWeightKg(stats.TotalWeightKg)
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal fisher report: %v", err)
+	}
+
+	return string(reportBytes), nil
+}
+
+// SalesReport summarises delivered orders over a date range
+type SalesReport struct {
+	Orders            int                `json:"orders"`
+	TotalVolumeKg     float64            `json:"totalVolumeKg"`
+	AveragePrice      float64            `json:"averagePrice"`
+	RevenueByCurrency map[string]float64 `json:"revenueByCurrency"`
+	Skipped           int                `json:"skipped"`
+}
+
+// GenerateSalesReport returns the volume and volume-weighted average price of orders delivered
+// between dates, plus their order amounts per currency; orders with any batch or price quote
+// missing are counted as skipped for volume and price
+func (s *SmartContract) GenerateSalesReport(ctx contractapi.TransactionContextInterface, startDate, endDate string) (string, error) {
+	if !s.hasRole(ctx, "authority") {
+		return "", unauthorized("only authority can generate sales reports")
+	}
+
+	orders, err := ordersMatching(ctx, func(order *Order) bool {
+		return order.Status == "delivered" && order.Date >= startDate && order.Date <= endDate
+	})
+	if err != nil {
+		return "", err
+	}
+
+	report := SalesReport{RevenueByCurrency: map[string]float64{}}
+	var totalValue float64
+	for _, order := range orders {
+		// Orders placed before amounts were recorded have no currency
+		if order.Currency != "" {
+			report.RevenueByCurrency[order.Currency] += order.Amount
+		}
+
+		var volumeKg, valueSum float64
+		complete := true
+		for _, batchId := range order.BatchIDs {
+			batch, err := s.GetBatch(ctx, batchId)
+			if err != nil {
+				complete = false
+				break
+			}
+			quote, err := readPriceQuote(ctx, batchId)
+			if err != nil {
+				complete = false
+				break
+			}
+
+			// Orders placed by quantity only sold the share of the batch allocated to them
+			batchKg, allocated := order.AllocatedKg[batchId]
+			if !allocated {
+				for _, catchId := range batch.CatchIDs {
+					catch, err := s.GetCatch(ctx, catchId)
+					if err != nil {
+						continue
+					}
+					batchKg += catch.WeightKg
+				}
+			}
+			volumeKg += batchKg
+			valueSum += quote.Price * batchKg
+		}
+		if !complete {
+			report.Skipped++
+			continue
+		}
+
+		report.Orders++
+		report.TotalVolumeKg += volumeKg
+		totalValue += valueSum
+	}
+	if report.TotalVolumeKg > 0 {
+		report.AveragePrice = totalValue / report.TotalVolumeKg
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sales report: %v", err)
+	}
+
+	return string(reportBytes), nil
+}
+
+// catchesInRange returns every catch whose date falls between startDate and endDate inclusive;
+// archived catches are only included when includeArchived is set
+func catchesInRange(ctx contractapi.TransactionContextInterface, startDate, endDate string, includeArchived bool) ([]Catch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var catches []Catch
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+
+		if catch.Archived && !includeArchived {
+			continue
+		}
+		if catch.Date >= startDate && catch.Date <= endDate {
+			catches = append(catches, catch)
+		}
+	}
+
+	return catches, nil
+}
+
+// LedgerSummary counts the records of each entity type
+type LedgerSummary struct {
+	Fishers int `json:"fishers"`
+	Catches int `json:"catches"`
+	Batches int `json:"batches"`
+	Orders  int `json:"orders"`
+}
+
+// GetLedgerSummary returns the number of fishers, catches, batches and orders as JSON
+func (s *SmartContract) GetLedgerSummary(ctx contractapi.TransactionContextInterface) (string, error) {
+	var summary LedgerSummary
+
+	fisherIterator, err := ctx.GetStub().GetPrivateDataByRange("FisherCollection", "FISHER_", prefixEnd("FISHER_"))
+	if err != nil {
+		return "", fmt.Errorf("failed to get fishers by range: %v", err)
+	}
+	defer fisherIterator.Close()
+	for fisherIterator.HasNext() {
+		if _, err := fisherIterator.Next(); err != nil {
+			return "", fmt.Errorf("failed during results iteration: %v", err)
+		}
+		summary.Fishers++
+	}
+
+	if summary.Catches, err = countKeys(ctx, "CATCH_"); err != nil {
+		return "", err
+	}
+	if summary.Batches, err = countKeys(ctx, "BATCH_"); err != nil {
+		return "", err
+	}
+	if summary.Orders, err = countKeys(ctx, "ORDER_"); err != nil {
+		return "", err
+	}
+
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ledger summary: %v", err)
+	}
+	return string(summaryBytes), nil
+}
+
+// countKeys counts the world state keys under a prefix without unmarshalling their values
+func countKeys(ctx contractapi.TransactionContextInterface, prefix string) (int, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange(prefix, prefixEnd(prefix))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s keys by range: %v", prefix, err)
+	}
+	defer resultsIterator.Close()
+
+	count := 0
+	for resultsIterator.HasNext() {
+		if _, err := resultsIterator.Next(); err != nil {
+			return 0, fmt.Errorf("failed during results iteration: %v", err)
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// validShipmentStatuses are the statuses a shipment may be in
+var validShipmentStatuses = map[string]bool{"in_transit": true, "delivered": true, "returned": true}
+
+// CreateShipment ships a confirmed order and moves the order to "shipped"
+func (s *SmartContract) CreateShipment(ctx contractapi.TransactionContextInterface, shipmentId, orderId, carrier, trackingNo, date string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can create shipments")
+	}
+
+	if err := requireNonEmpty("shipmentId", shipmentId); err != nil {
+		return err
+	}
+	if err := validateDate(date); err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetState("SHIPMENT_" + shipmentId)
+	if err != nil {
+		return fmt.Errorf("failed to read shipment %s: %v", shipmentId, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("shipment %s already exists", shipmentId)
+	}
+
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if order.Status != "confirmed" {
+		return fmt.Errorf("order %s must be confirmed before shipping, status is %s", orderId, order.Status)
+	}
+	if err := checkNotHeld(ctx, order); err != nil {
+		return err
+	}
+
+	shipment := Shipment{
+		ShipmentID: shipmentId,
+		OrderID:    orderId,
+		Carrier:    carrier,
+		TrackingNo: trackingNo,
+		Status:     "in_transit",
+		Date:       date,
+	}
+	shipmentBytes, err := json.Marshal(shipment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal shipment data: %v", err)
+	}
+	err = ctx.GetStub().PutState("SHIPMENT_"+shipmentId, shipmentBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put shipment %s: %v", shipmentId, err)
+	}
+
+	order.Status = "shipped"
+	orderBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order data: %v", err)
+	}
+	err = ctx.GetStub().PutState("ORDER_"+orderId, orderBytes)
+	if err != nil {
+		return fmt.Errorf("failed to put order %s: %v", orderId, err)
+	}
+
+	for _, batchId := range order.BatchIDs {
+		if err := s.markBatchShipped(ctx, batchId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markBatchShipped stamps a batch's ShippedAt on its first shipment
+func (s *SmartContract) markBatchShipped(ctx contractapi.TransactionContextInterface, batchId string) error {
+	batch, err := s.GetBatch(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if batch.ShippedAt != "" {
+		return nil
+	}
+
+	batch.ShippedAt, err = txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch data: %v", err)
+	}
+	return ctx.GetStub().PutState("BATCH_"+batchId, batchBytes)
+}
+
+// GetShipment retrieves a shipment by ID
+func (s *SmartContract) GetShipment(ctx contractapi.TransactionContextInterface, shipmentId string) (*Shipment, error) {
+	shipmentBytes, err := ctx.GetStub().GetState("SHIPMENT_" + shipmentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shipment %s: %v", shipmentId, err)
+	}
+	if shipmentBytes == nil {
+		return nil, notFound("shipment %s not found", shipmentId)
+	}
+
+	var shipment Shipment
+	err = json.Unmarshal(shipmentBytes, &shipment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shipment data: %v", err)
+	}
+
+	return &shipment, nil
+}
+
+// UpdateShipmentStatus records carrier progress on a shipment
+func (s *SmartContract) UpdateShipmentStatus(ctx contractapi.TransactionContextInterface, shipmentId, status string) error {
+	if !s.hasRole(ctx, "processor") {
+		return unauthorized("only processor can update shipments")
+	}
+	if !validShipmentStatuses[status] {
+		return fmt.Errorf("unknown shipment status %q", status)
+	}
+
+	shipment, err := s.GetShipment(ctx, shipmentId)
+	if err != nil {
+		return err
+	}
+	shipment.Status = status
+
+	shipmentBytes, err := json.Marshal(shipment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal shipment data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("SHIPMENT_"+shipmentId, shipmentBytes)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// legacySpeciesKey holds whether catches may still use free-text species instead of registered codes
+const legacySpeciesKey = "CFG_LEGACY_SPECIES"
+
+// RegisterSpecies adds a species code to the registry that catches are validated against
+func (s *SmartContract) RegisterSpecies(ctx contractapi.TransactionContextInterface, code, commonName, scientificName string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can register species")
+	}
+
+	if err := requireNonEmpty("code", code); err != nil {
+		return err
+	}
+	if err := requireMaxLength("code", code, maxSpeciesLength); err != nil {
+		return err
+	}
+
+	existing, err := ctx.GetStub().GetState("SPECIES_" + code)
+	if err != nil {
+		return fmt.Errorf("failed to read species %s: %v", code, err)
+	}
+	if existing != nil {
+		return fmt.Errorf("species %s already exists", code)
+	}
+
+	species := Species{
+		Code:           code,
+		CommonName:     commonName,
+		ScientificName: scientificName,
+	}
+	speciesBytes, err := json.Marshal(species)
+	if err != nil {
+		return fmt.Errorf("failed to marshal species data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("SPECIES_"+code, speciesBytes)
+}
+
+// GetSpecies retrieves a registered species by code
+func (s *SmartContract) GetSpecies(ctx contractapi.TransactionContextInterface, code string) (*Species, error) {
+	speciesBytes, err := ctx.GetStub().GetState("SPECIES_" + code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read species %s: %v", code, err)
+	}
+	if speciesBytes == nil {
+		return nil, notFound("species %s not found", code)
+	}
+
+	var species Species
+	err = json.Unmarshal(speciesBytes, &species)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal species data: %v", err)
+	}
+
+	return &species, nil
+}
+
+// SetLegacySpeciesAllowed lets catches use unregistered free-text species while existing clients migrate to codes
+func (s *SmartContract) SetLegacySpeciesAllowed(ctx contractapi.TransactionContextInterface, allowed bool) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can change legacy species handling")
+	}
+	return ctx.GetStub().PutState(legacySpeciesKey, []byte(strconv.FormatBool(allowed)))
+}
+
+// checkSpeciesCode rejects a species that isn't in the registry, unless legacy free text is allowed
+func checkSpeciesCode(ctx contractapi.TransactionContextInterface, species string) error {
+	speciesBytes, err := ctx.GetStub().GetState("SPECIES_" + species)
+	if err != nil {
+		return fmt.Errorf("failed to read species %s: %v", species, err)
+	}
+	if speciesBytes != nil {
+		return nil
+	}
+
+	legacyBytes, err := ctx.GetStub().GetState(legacySpeciesKey)
+	if err != nil {
+		return fmt.Errorf("failed to read legacy species setting: %v", err)
+	}
+	if string(legacyBytes) == "true" {
+		return nil
+	}
+	return fmt.Errorf("unknown species code %q", species)
+}
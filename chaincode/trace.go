@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CatchTrace is a catch together with the fisher who landed it
+type CatchTrace struct {
+	Catch  *Catch  `json:"catch"`
+	Fisher *Fisher `json:"fisher"`
+}
+
+// CatchProvenance is what a single catch's QR code resolves to
+type CatchProvenance struct {
+	Catch   *Catch   `json:"catch"`
+	Fisher  *Fisher  `json:"fisher"`
+	Vessel  *Vessel  `json:"vessel,omitempty"`
+	Missing []string `json:"missing"`
+}
+
+// OrderTrace is the full provenance chain behind an order
+type OrderTrace struct {
+	Order   *Order       `json:"order"`
+	Batches []*Batch     `json:"batches"`
+	Catches []CatchTrace `json:"catches"`
+	Missing []string     `json:"missing"`
+}
+
+// TraceOrder assembles order -> batches -> catches -> fishers into one JSON document.
+// Broken links are listed under "missing" instead of being silently dropped.
+func (s *SmartContract) TraceOrder(ctx contractapi.TransactionContextInterface, orderId string) (string, error) {
+	order, err := s.GetOrder(ctx, orderId)
+	if err != nil {
+		return "", err
+	}
+
+	trace := OrderTrace{Order: order, Batches: []*Batch{}, Catches: []CatchTrace{}, Missing: []string{}}
+
+	for _, batchId := range order.BatchIDs {
+		batch, err := s.GetBatch(ctx, batchId)
+		if err != nil {
+			trace.Missing = append(trace.Missing, err.Error())
+			continue
+		}
+		trace.Batches = append(trace.Batches, batch)
+
+		for _, catchId := range batch.CatchIDs {
+			catch, err := s.GetCatch(ctx, catchId)
+			if err != nil {
+				trace.Missing = append(trace.Missing, err.Error())
+				continue
+			}
+
+			fisher, err := s.GetFisherPublic(ctx, catch.FisherID)
+			if err != nil {
+				trace.Missing = append(trace.Missing, err.Error())
+			}
+
+			trace.Catches = append(trace.Catches, CatchTrace{Catch: catch, Fisher: fisher})
+		}
+	}
+
+	traceBytes, err := json.Marshal(trace)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal trace data: %v", err)
+	}
+
+	return string(traceBytes), nil
+}
+
+// GetCatchProvenance returns a catch with its fisher's public fields and, when recorded, its vessel.
+// A missing fisher or vessel is listed under "missing" rather than failing the call.
+func (s *SmartContract) GetCatchProvenance(ctx contractapi.TransactionContextInterface, catchId string) (string, error) {
+	catch, err := s.GetCatch(ctx, catchId)
+	if err != nil {
+		return "", err
+	}
+
+	provenance := CatchProvenance{Catch: catch, Missing: []string{}}
+
+	provenance.Fisher, err = s.GetFisherPublic(ctx, catch.FisherID)
+	if err != nil {
+		provenance.Missing = append(provenance.Missing, err.Error())
+	}
+
+	if catch.VesselID != "" {
+		provenance.Vessel, err = s.GetVessel(ctx, catch.VesselID)
+		if err != nil {
+			provenance.Missing = append(provenance.Missing, err.Error())
+		}
+	}
+
+	provenanceBytes, err := json.Marshal(provenance)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal provenance data: %v", err)
+	}
+
+	return string(provenanceBytes), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ValidationCheck is the outcome of a single consistency check
+type ValidationCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// declaredWeightToleranceKg is how far a batch's declared weight may drift from its catches' total
+// before ValidateSupplyChain flags it
+const declaredWeightToleranceKg = 0.5
+
+// SupplyChainReport collects every check run against an order's supply chain
+type SupplyChainReport struct {
+	OrderID string            `json:"orderId"`
+	Valid   bool              `json:"valid"`
+	Checks  []ValidationCheck `json:"checks"`
+}
+
+func (r *SupplyChainReport) add(name string, passed bool, detail string) {
+	r.Checks = append(r.Checks, ValidationCheck{Name: name, Passed: passed, Detail: detail})
+	if !passed {
+		r.Valid = false
+	}
+}
+
+// ValidateSupplyChain walks order -> batches -> catches -> fishers and reports every check
+// rather than stopping at the first failure
+func (s *SmartContract) ValidateSupplyChain(ctx contractapi.TransactionContextInterface, orderId string) (*SupplyChainReport, error) {
+	if !s.hasRole(ctx, "authority") {
+		return nil, unauthorized("only authority can validate supply chains")
+	}
+
+	report := &SupplyChainReport{OrderID: orderId, Valid: true, Checks: []ValidationCheck{}}
+
+	orderBytes, err := ctx.GetStub().GetState("ORDER_" + orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order %s: %v", orderId, err)
+	}
+	if orderBytes == nil {
+		report.add("order exists", false, fmt.Sprintf("order %s not found", orderId))
+		return report, nil
+	}
+	var order Order
+	if err := json.Unmarshal(orderBytes, &order); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order data: %v", err)
+	}
+	report.add("order exists", true, "")
+
+	report.add("order has batches", len(order.BatchIDs) > 0, "")
+	for _, batchId := range order.BatchIDs {
+		if err := validateOrderBatch(ctx, report, &order, batchId); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// validateOrderBatch adds the checks for one of an order's batches and its catches to the report
+func validateOrderBatch(ctx contractapi.TransactionContextInterface, report *SupplyChainReport, order *Order, batchId string) error {
+	batchBytes, err := ctx.GetStub().GetState("BATCH_" + batchId)
+	if err != nil {
+		return fmt.Errorf("failed to read batch %s: %v", batchId, err)
+	}
+	if batchBytes == nil {
+		report.add("batch "+batchId+" exists", false, fmt.Sprintf("batch %s not found", batchId))
+		return nil
+	}
+	var batch Batch
+	if err := json.Unmarshal(batchBytes, &batch); err != nil {
+		return fmt.Errorf("failed to unmarshal batch data: %v", err)
+	}
+	report.add("batch "+batchId+" exists", true, "")
+	report.add("batch "+batchId+" has catches", len(batch.CatchIDs) > 0, "")
+	report.add("batch "+batchId+" not on hold", !batch.OnHold, batch.HoldReason)
+
+	var totalKg float64
+	for _, catchId := range batch.CatchIDs {
+		catchBytes, err := ctx.GetStub().GetState("CATCH_" + catchId)
+		if err != nil {
+			return fmt.Errorf("failed to read catch %s: %v", catchId, err)
+		}
+		if catchBytes == nil {
+			report.add("catch "+catchId+" exists", false, fmt.Sprintf("catch %s not found", catchId))
+			continue
+		}
+		var catch Catch
+		if err := json.Unmarshal(catchBytes, &catch); err != nil {
+			return fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+		report.add("catch "+catchId+" exists", true, "")
+		report.add("catch "+catchId+" weight", catch.WeightKg > 0, fmt.Sprintf("%.3f kg", catch.WeightKg))
+		report.add("catch "+catchId+" not condemned", !catch.Condemned, catch.CondemnReason)
+		totalKg += catch.WeightKg
+
+		fisher, err := readFisher(ctx, catch.FisherID)
+		if isNotFound(err) {
+			report.add("fisher "+catch.FisherID+" exists", false, err.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.add("fisher "+catch.FisherID+" exists", true, "")
+		report.add("fisher "+catch.FisherID+" active", fisher.Active, "")
+	}
+
+	if batch.DeclaredWeightKg > 0 {
+		report.add("batch "+batchId+" weight", math.Abs(batch.DeclaredWeightKg-totalKg) <= declaredWeightToleranceKg,
+			fmt.Sprintf("%.3f kg declared, %.3f kg from catches", batch.DeclaredWeightKg, totalKg))
+	} else {
+		report.add("batch "+batchId+" weight", totalKg > 0, fmt.Sprintf("%.3f kg from catches", totalKg))
+	}
+	if batch.BestBefore != "" {
+		report.add("batch "+batchId+" fresh at order", order.Date <= batch.BestBefore, fmt.Sprintf("best before %s, ordered %s", batch.BestBefore, order.Date))
+	}
+
+	return nil
+}
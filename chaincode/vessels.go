@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterVessel allows an authority to register a fishing vessel owned by a registered fisher
+func (s *SmartContract) RegisterVessel(ctx contractapi.TransactionContextInterface, id, name, registrationNo, ownerFisherId string) error {
+	if !s.hasRole(ctx, "authority") {
+		return unauthorized("only authority can register vessels")
+	}
+
+	exists, err := s.VesselExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("vessel %s already exists", id)
+	}
+
+	if _, err := s.GetFisher(ctx, ownerFisherId); err != nil {
+		return err
+	}
+
+	vessel := Vessel{
+		ID:             id,
+		Name:           name,
+		RegistrationNo: registrationNo,
+		OwnerFisherID:  ownerFisherId,
+	}
+
+	vesselBytes, err := json.Marshal(vessel)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vessel data: %v", err)
+	}
+
+	return ctx.GetStub().PutState("VESSEL_"+id, vesselBytes)
+}
+
+// GetVessel retrieves a vessel by ID
+func (s *SmartContract) GetVessel(ctx contractapi.TransactionContextInterface, vesselId string) (*Vessel, error) {
+	vesselBytes, err := ctx.GetStub().GetState("VESSEL_" + vesselId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vessel %s: %v", vesselId, err)
+	}
+	if vesselBytes == nil {
+		return nil, notFound("vessel %s not found", vesselId)
+	}
+
+	var vessel Vessel
+	err = json.Unmarshal(vesselBytes, &vessel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vessel data: %v", err)
+	}
+
+	return &vessel, nil
+}
+
+// VesselExists returns true when a vessel with the given ID is on the ledger
+func (s *SmartContract) VesselExists(ctx contractapi.TransactionContextInterface, vesselId string) (bool, error) {
+	vesselBytes, err := ctx.GetStub().GetState("VESSEL_" + vesselId)
+	if err != nil {
+		return false, fmt.Errorf("failed to read vessel %s: %v", vesselId, err)
+	}
+	return vesselBytes != nil, nil
+}
+
+// GetCatchesByVessel returns every catch landed by the given vessel; archived catches are
+// only included when includeArchived is set
+func (s *SmartContract) GetCatchesByVessel(ctx contractapi.TransactionContextInterface, vesselId string, includeArchived bool) ([]*Catch, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("CATCH_", prefixEnd("CATCH_"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catches by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	catches := []*Catch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed during results iteration: %v", err)
+		}
+
+		var catch Catch
+		err = json.Unmarshal(queryResponse.Value, &catch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal catch data: %v", err)
+		}
+
+		if catch.Archived && !includeArchived {
+			continue
+		}
+		if catch.VesselID == vesselId {
+			catches = append(catches, &catch)
+		}
+	}
+
+	return catches, nil
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func setupStub(t *testing.T) (*shimtest.MockStub, *contractapi.MockTransactionContext) {
@@ -14,6 +22,45 @@ func setupStub(t *testing.T) (*shimtest.MockStub, *contractapi.MockTransactionCo
 	return stub, ctx
 }
 
+func isContractError(err error, code, message string) bool {
+	var contractErr *ContractError
+	return errors.As(err, &contractErr) && contractErr.Code == code && contractErr.Message == message
+}
+
+func seedFisher(stub *shimtest.MockStub, fisherID string) {
+	fisherBytes, _ := json.Marshal(Fisher{ID: fisherID, Name: "Fisher " + fisherID, Role: "fisher", Active: true})
+	stub.PutPrivateData("FisherCollection", "FISHER_"+fisherID, fisherBytes)
+	piiBytes, _ := json.Marshal(FisherPII{ID: fisherID, GovtID: "GOV_" + fisherID})
+	stub.PutPrivateData("FisherPIICollection", "FISHER_"+fisherID, piiBytes)
+}
+
+func seedSpecies(stub *shimtest.MockStub, codes ...string) {
+	for _, code := range codes {
+		speciesBytes, _ := json.Marshal(Species{Code: code, CommonName: code})
+		stub.PutState("SPECIES_"+code, speciesBytes)
+	}
+}
+
+func seedLicense(stub *shimtest.MockStub, fisherID string) {
+	licenseBytes, _ := json.Marshal(License{LicenseID: "L_" + fisherID, FisherID: fisherID, IssuedDate: "2025-01-01", ExpiryDate: "2025-12-31", Active: true})
+	stub.PutState("LICENSE_L_"+fisherID, licenseBytes)
+}
+
+func listFisherCatches(ctx *contractapi.MockTransactionContext, fisherID string, includeArchived bool) ([]Catch, error) {
+	page, err := (&SmartContract{}).GetCatchesByFisher(ctx, fisherID, 100, "", "", includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	return page.Catches, nil
+}
+
+func seedCatch(stub *shimtest.MockStub, catch Catch) {
+	catchBytes, _ := json.Marshal(catch)
+	stub.PutState("CATCH_"+catch.CatchID, catchBytes)
+	indexKey, _ := stub.CreateCompositeKey("fisher~catch", []string{catch.FisherID, catch.CatchID})
+	stub.PutState(indexKey, []byte{0x00})
+}
+
 func TestRegisterFisher(t *testing.T) {
 	stub, ctx := setupStub(t)
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
@@ -30,25 +77,28 @@ func TestRegisterFisher(t *testing.T) {
 
 	// Duplicate ID
 	err = (&SmartContract{}).RegisterFisher(ctx, "F001", "Jane Doe", "GOV456")
-	if err == nil {
+	if err == nil || err.Error() != "fisher F001 already exists" {
 		t.Error("RegisterFisher should fail on duplicate ID")
 	}
 
 	// Unauthorized access
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
 	err = (&SmartContract{}).RegisterFisher(ctx, "F002", "Jane Doe", "GOV456")
-	if err == nil || err.Error() != "only authority can register fishers" {
+	if !isContractError(err, "UNAUTHORIZED", "only authority can register fishers") {
 		t.Error("RegisterFisher should fail for non-authority")
 	}
 }
 
 func TestLogCatch(t *testing.T) {
 	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
 	ctx.GetClientIdentity().SetID("F001")
 
 	// Success case
-	err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", 10.5, "2025-08-09")
+	err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
 	if err != nil {
 		t.Errorf("LogCatch failed: %v", err)
 	}
@@ -58,23 +108,23 @@ func TestLogCatch(t *testing.T) {
 	}
 
 	// Invalid weight
-	err = (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", -1.0, "2025-08-09")
+	err = (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "-1.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
 	if err == nil || err.Error() != "weight must be positive" {
 		t.Error("LogCatch should fail for invalid weight")
 	}
 
 	// Unauthorized fisher
 	ctx.GetClientIdentity().SetID("F002")
-	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", 5.0, "2025-08-09")
-	if err == nil || err.Error() != "only the fisher can log their catch" {
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "5.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if !isContractError(err, "UNAUTHORIZED", "only the fisher can log their catch") {
 		t.Error("LogCatch should fail for unauthorized fisher")
 	}
 
 	// Non-fisher role
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
 	ctx.GetClientIdentity().SetID("F001")
-	err = (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Tilapia", 5.0, "2025-08-09")
-	if err == nil || err.Error() != "only the fisher can log their catch" {
+	err = (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Tilapia", "5.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if !isContractError(err, "UNAUTHORIZED", "only the fisher can log their catch") {
 		t.Error("LogCatch should fail for non-fisher role")
 	}
 }
@@ -83,9 +133,15 @@ func TestCreateBatch(t *testing.T) {
 	stub, ctx := setupStub(t)
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
 
+	// Seed catches
+	catch1Bytes, _ := json.Marshal(Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	catch2Bytes, _ := json.Marshal(Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+	stub.PutState("CATCH_C001", catch1Bytes)
+	stub.PutState("CATCH_C002", catch2Bytes)
+
 	// Success case
 	catchIds := []string{"C001", "C002"}
-	err := (&SmartContract{}).CreateBatch(ctx, "B001", catchIds, "P001", "2025-08-09")
+	err := (&SmartContract{}).CreateBatch(ctx, "B001", catchIds, "P001", "2025-08-09", "", "", "")
 	if err != nil {
 		t.Errorf("CreateBatch failed: %v", err)
 	}
@@ -94,10 +150,31 @@ func TestCreateBatch(t *testing.T) {
 		t.Error("Batch B001 should exist")
 	}
 
+	// Duplicate ID
+	err = (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C002"}, "P001", "2025-08-09", "", "", "")
+	if err == nil || err.Error() != "batch B001 already exists" {
+		t.Errorf("CreateBatch should reject an existing batch ID, got %v", err)
+	}
+	if batch, _ := (&SmartContract{}).GetBatch(ctx, "B001"); len(batch.CatchIDs) != 2 {
+		t.Errorf("existing batch B001 should be unchanged, got %+v", batch)
+	}
+
+	// Missing catch
+	err = (&SmartContract{}).CreateBatch(ctx, "B003", []string{"C001", "C999"}, "P001", "2025-08-09", "", "", "")
+	if err == nil || err.Error() != "catch C999 does not exist" {
+		t.Error("CreateBatch should fail for a missing catch")
+	}
+
+	// Empty catch list
+	err = (&SmartContract{}).CreateBatch(ctx, "B004", []string{}, "P001", "2025-08-09", "", "", "")
+	if err == nil || err.Error() != "batch must contain at least one catch" {
+		t.Error("CreateBatch should fail for an empty catch list")
+	}
+
 	// Unauthorized access
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
-	err = (&SmartContract{}).CreateBatch(ctx, "B002", catchIds, "P001", "2025-08-09")
-	if err == nil || err.Error() != "only processor can create batches" {
+	err = (&SmartContract{}).CreateBatch(ctx, "B002", catchIds, "P001", "2025-08-09", "", "", "")
+	if !isContractError(err, "UNAUTHORIZED", "only processor can create batches") {
 		t.Error("CreateBatch should fail for non-processor")
 	}
 }
@@ -119,17 +196,19 @@ func TestTrackBatch(t *testing.T) {
 
 	// Non-existent batch
 	_, err = (&SmartContract{}).TrackBatch(ctx, "B002")
-	if err == nil || err.Error() != "batch B002 not found" {
+	if !isContractError(err, "NOT_FOUND", "batch B002 not found") {
 		t.Error("TrackBatch should fail for non-existent batch")
 	}
 }
 
 func TestPlaceOrder(t *testing.T) {
 	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
 
 	// Success case
-	err := (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-09")
+	err := (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-09", "250", "USD", "", "")
 	if err != nil {
 		t.Errorf("PlaceOrder failed: %v", err)
 	}
@@ -138,10 +217,28 @@ func TestPlaceOrder(t *testing.T) {
 		t.Error("Order O001 should exist")
 	}
 
+	// Duplicate ID
+	err = (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY002", "2025-08-10", "300", "USD", "", "")
+	if err == nil || err.Error() != "order O001 already exists" {
+		t.Errorf("PlaceOrder should reject an existing order ID, got %v", err)
+	}
+	if order, _ := (&SmartContract{}).GetOrder(ctx, "O001"); order.BuyerID != "BUY001" {
+		t.Errorf("existing order O001 should be unchanged, got %+v", order)
+	}
+
+	// Missing batch
+	err = (&SmartContract{}).PlaceOrder(ctx, "O002", "B999", "BUY001", "2025-08-09", "250", "USD", "", "")
+	if err == nil || err.Error() != "cannot place order: batch B999 not found" {
+		t.Errorf("PlaceOrder should fail for a missing batch, got %v", err)
+	}
+	if orderBytes, _ := stub.GetState("ORDER_O002"); orderBytes != nil {
+		t.Error("order for a missing batch should not be stored")
+	}
+
 	// Unauthorized access
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
-	err = (&SmartContract{}).PlaceOrder(ctx, "O002", "B001", "BUY001", "2025-08-09")
-	if err == nil || err.Error() != "only buyer can place orders" {
+	err = (&SmartContract{}).PlaceOrder(ctx, "O002", "B001", "BUY001", "2025-08-09", "250", "USD", "", "")
+	if !isContractError(err, "UNAUTHORIZED", "only buyer can place orders") {
 		t.Error("PlaceOrder should fail for non-buyer")
 	}
 }
@@ -159,7 +256,7 @@ func TestGenerateReport(t *testing.T) {
 	stub.PutState("CATCH_C002", catch2Bytes)
 
 	// Success case
-	result, err := (&SmartContract{}).GenerateReport(ctx, "2025-08-09", "2025-08-10")
+	result, err := (&SmartContract{}).GenerateReport(ctx, "2025-08-09", "2025-08-10", false)
 	if err != nil {
 		t.Errorf("GenerateReport failed: %v", err)
 	}
@@ -169,8 +266,3934 @@ func TestGenerateReport(t *testing.T) {
 
 	// Unauthorized access
 	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
-	_, err = (&SmartContract{}).GenerateReport(ctx, "2025-08-09", "2025-08-10")
-	if err == nil || err.Error() != "only authority can generate reports" {
+	_, err = (&SmartContract{}).GenerateReport(ctx, "2025-08-09", "2025-08-10", false)
+	if !isContractError(err, "UNAUTHORIZED", "only authority can generate reports") {
 		t.Error("GenerateReport should fail for non-authority")
 	}
 }
+
+func TestValidateSupplyChain(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+
+	// Seed a full chain with one missing catch
+	fisherBytes, _ := json.Marshal(Fisher{ID: "F001", Name: "John Doe", GovtID: "GOV123", Role: "fisher", Active: true})
+	stub.PutPrivateData("FisherCollection", "FISHER_F001", fisherBytes)
+	catchBytes, _ := json.Marshal(Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	stub.PutState("CATCH_C001", catchBytes)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C999"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY001", Status: "placed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	report, err := (&SmartContract{}).ValidateSupplyChain(ctx, "O001")
+	if err != nil {
+		t.Fatalf("ValidateSupplyChain failed: %v", err)
+	}
+	if report.Valid {
+		t.Error("report should be invalid when a catch is missing")
+	}
+	failed := 0
+	for _, check := range report.Checks {
+		if !check.Passed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected exactly 1 failed check, got %d", failed)
+	}
+
+	// Held batch, condemned catch and a declared weight far from the catch total
+	catchBytes, _ = json.Marshal(Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09", Condemned: true, CondemnReason: "spoiled"})
+	stub.PutState("CATCH_C002", catchBytes)
+	batchBytes, _ = json.Marshal(Batch{BatchID: "B002", CatchIDs: []string{"C001", "C002"}, ProcessorID: "P001", Date: "2025-08-09",
+		DeclaredWeightKg: 20, OnHold: true, HoldReason: "histamine test"})
+	stub.PutState("BATCH_B002", batchBytes)
+	orderBytes, _ = json.Marshal(Order{OrderID: "O002", BatchID: "B002", BuyerID: "BUY001", Status: "placed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O002", orderBytes)
+
+	report, err = (&SmartContract{}).ValidateSupplyChain(ctx, "O002")
+	if err != nil {
+		t.Fatalf("ValidateSupplyChain failed: %v", err)
+	}
+	failedChecks := map[string]string{}
+	for _, check := range report.Checks {
+		if !check.Passed {
+			failedChecks[check.Name] = check.Detail
+		}
+	}
+	want := map[string]string{
+		"batch B002 not on hold":   "histamine test",
+		"catch C002 not condemned": "spoiled",
+		"batch B002 weight":        "20.000 kg declared, 14.500 kg from catches",
+	}
+	if report.Valid || !reflect.DeepEqual(failedChecks, want) {
+		t.Errorf("expected failed checks %v, got %v", want, failedChecks)
+	}
+
+	// A declared weight within tolerance passes
+	batchBytes, _ = json.Marshal(Batch{BatchID: "B002", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09", DeclaredWeightKg: 10.2})
+	stub.PutState("BATCH_B002", batchBytes)
+	report, _ = (&SmartContract{}).ValidateSupplyChain(ctx, "O002")
+	if !report.Valid {
+		t.Errorf("expected a valid report, got %+v", report)
+	}
+
+	// A missing fisher fails its check, but an unreadable fisher record is an error
+	catchBytes, _ = json.Marshal(Catch{CatchID: "C003", FisherID: "F002", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	stub.PutState("CATCH_C003", catchBytes)
+	batchBytes, _ = json.Marshal(Batch{BatchID: "B002", CatchIDs: []string{"C003"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B002", batchBytes)
+	report, err = (&SmartContract{}).ValidateSupplyChain(ctx, "O002")
+	if err != nil || report.Valid {
+		t.Errorf("expected an invalid report for a missing fisher, got %+v, %v", report, err)
+	}
+	stub.PutPrivateData("FisherCollection", "FISHER_F002", []byte("not json"))
+	if _, err := (&SmartContract{}).ValidateSupplyChain(ctx, "O002"); err == nil {
+		t.Error("ValidateSupplyChain should return an error for an unreadable fisher record")
+	}
+
+	// Unauthorized access
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	_, err = (&SmartContract{}).ValidateSupplyChain(ctx, "O001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can validate supply chains") {
+		t.Error("ValidateSupplyChain should fail for non-authority")
+	}
+}
+
+func TestCatchExists(t *testing.T) {
+	stub, ctx := setupStub(t)
+	catchBytes, _ := json.Marshal(Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	stub.PutState("CATCH_C001", catchBytes)
+
+	exists, err := (&SmartContract{}).CatchExists(ctx, "C001")
+	if err != nil || !exists {
+		t.Errorf("CatchExists should be true for C001, got %v (%v)", exists, err)
+	}
+
+	exists, err = (&SmartContract{}).CatchExists(ctx, "C002")
+	if err != nil || exists {
+		t.Errorf("CatchExists should be false for C002, got %v (%v)", exists, err)
+	}
+}
+
+func TestBatchExists(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	exists, err := (&SmartContract{}).BatchExists(ctx, "B001")
+	if err != nil || !exists {
+		t.Errorf("BatchExists should be true for B001, got %v (%v)", exists, err)
+	}
+
+	exists, err = (&SmartContract{}).BatchExists(ctx, "B002")
+	if err != nil || exists {
+		t.Errorf("BatchExists should be false for B002, got %v (%v)", exists, err)
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	stub, ctx := setupStub(t)
+	order := Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY001", Status: "placed", Date: "2025-08-09", Amount: 250, Currency: "USD"}
+	orderBytes, _ := json.Marshal(order)
+	stub.PutState("ORDER_O001", orderBytes)
+
+	// Success case
+	result, err := (&SmartContract{}).GetOrder(ctx, "O001")
+	if err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	// Orders stored with only BatchID are read back with it in BatchIDs
+	want := order
+	want.BatchIDs = []string{"B001"}
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("GetOrder returned %+v, want %+v", *result, want)
+	}
+
+	// Non-existent order
+	_, err = (&SmartContract{}).GetOrder(ctx, "O002")
+	if !isContractError(err, "NOT_FOUND", "order O002 not found") {
+		t.Error("GetOrder should fail for non-existent order")
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	stub, ctx := setupStub(t)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BatchIDs: []string{"B001"}, BuyerID: "BUY001", Status: "placed", Date: "2025-08-09"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	// Illegal jump
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	err := (&SmartContract{}).UpdateOrderStatus(ctx, "O001", "delivered")
+	if err == nil || err.Error() != "invalid order status transition from placed to delivered" {
+		t.Error("UpdateOrderStatus should reject placed -> delivered")
+	}
+
+	// Wrong role for target status
+	err = (&SmartContract{}).UpdateOrderStatus(ctx, "O001", "confirmed")
+	if !isContractError(err, "UNAUTHORIZED", "only processor can set order status to confirmed") {
+		t.Error("UpdateOrderStatus should reject buyer confirming an order")
+	}
+
+	// Valid progression
+	steps := []struct{ role, status string }{
+		{"processor", "confirmed"},
+		{"processor", "shipped"},
+		{"buyer", "delivered"},
+	}
+	for _, step := range steps {
+		ctx.GetClientIdentity().SetAttributeValue("hf.Role", step.role)
+		if err := (&SmartContract{}).UpdateOrderStatus(ctx, "O001", step.status); err != nil {
+			t.Fatalf("UpdateOrderStatus to %s failed: %v", step.status, err)
+		}
+		if step.status == "shipped" {
+			if batch, _ := (&SmartContract{}).GetBatch(ctx, "B001"); batch.ShippedAt == "" {
+				t.Error("shipping the order should mark batch B001 shipped")
+			}
+		}
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if order.Status != "delivered" {
+		t.Errorf("expected status delivered, got %s", order.Status)
+	}
+}
+
+func TestGetCatchesByFisher(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, c := range []Catch{
+		{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"},
+		{CatchID: "C002", FisherID: "F002", Species: "Nile Perch", WeightKg: 15.0, Date: "2025-08-09"},
+		{CatchID: "C003", FisherID: "F001", Species: "Nile Perch", WeightKg: 7.0, Date: "2025-08-10"},
+	} {
+		seedCatch(stub, c)
+	}
+
+	catches, err := listFisherCatches(ctx, "F001", false)
+	if err != nil {
+		t.Fatalf("GetCatchesByFisher failed: %v", err)
+	}
+	if len(catches) != 2 {
+		t.Fatalf("expected 2 catches for F001, got %d", len(catches))
+	}
+	for _, c := range catches {
+		if c.FisherID != "F001" {
+			t.Errorf("unexpected catch %s for fisher %s", c.CatchID, c.FisherID)
+		}
+	}
+
+	// No catches
+	catches, err = listFisherCatches(ctx, "F003", false)
+	if err != nil || catches == nil || len(catches) != 0 {
+		t.Error("GetCatchesByFisher should return an empty slice for a fisher without catches")
+	}
+}
+
+func TestGetCatchesByFisherPaged(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, c := range []Catch{
+		{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 1, Date: "2025-08-09"},
+		{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 2, Date: "2025-08-09"},
+		{CatchID: "C003", FisherID: "F001", Species: "Tilapia", WeightKg: 3, Date: "2025-08-10"},
+		{CatchID: "C004", FisherID: "F001", Species: "Tilapia", WeightKg: 4, Date: "2025-08-10"},
+		{CatchID: "C005", FisherID: "F001", Species: "Nile Perch", WeightKg: 5, Date: "2025-08-11"},
+		{CatchID: "C006", FisherID: "F002", Species: "Tilapia", WeightKg: 6, Date: "2025-08-11"},
+	} {
+		seedCatch(stub, c)
+	}
+
+	// Page through all five catches two at a time
+	var seen []string
+	bookmark := ""
+	for pages := 0; pages < 5; pages++ {
+		page, err := (&SmartContract{}).GetCatchesByFisher(ctx, "F001", 2, bookmark, "", false)
+		if err != nil {
+			t.Fatalf("GetCatchesByFisher failed: %v", err)
+		}
+		if len(page.Catches) > 2 {
+			t.Errorf("page exceeds pageSize: %d", len(page.Catches))
+		}
+		for _, c := range page.Catches {
+			seen = append(seen, c.CatchID)
+		}
+		bookmark = page.Bookmark
+		if bookmark == "" {
+			break
+		}
+	}
+	if strings.Join(seen, ",") != "C001,C002,C003,C004,C005" {
+		t.Errorf("expected C001..C005 across pages, got %v", seen)
+	}
+
+	// Species filter narrows the page it is applied to
+	page, err := (&SmartContract{}).GetCatchesByFisher(ctx, "F001", 3, "", "Tilapia", false)
+	if err != nil {
+		t.Fatalf("GetCatchesByFisher with species failed: %v", err)
+	}
+	if len(page.Catches) != 2 || page.Catches[0].CatchID != "C001" || page.Catches[1].CatchID != "C003" {
+		t.Errorf("expected C001 and C003 from the first page of three, got %+v", page.Catches)
+	}
+
+	_, err = (&SmartContract{}).GetCatchesByFisher(ctx, "F001", 0, "", "", false)
+	if err == nil || err.Error() != "pageSize must be positive" {
+		t.Errorf("GetCatchesByFisher should reject a zero pageSize, got %v", err)
+	}
+}
+
+func TestChaincodeEvents(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+
+	expectEvent := func(name string) {
+		t.Helper()
+		select {
+		case event := <-stub.ChaincodeEventsChannel:
+			if event.EventName != name {
+				t.Errorf("expected event %s, got %s", name, event.EventName)
+			}
+		default:
+			t.Errorf("expected event %s, none recorded", name)
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	expectEvent("CatchLogged")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001"}, "P001", "2025-08-09", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	expectEvent("BatchCreated")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if err := (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-10", "250", "USD", "", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	expectEvent("OrderPlaced")
+}
+
+func TestRecordedAt(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	catchBytes, _ := stub.GetState("CATCH_C001")
+	var catch Catch
+	json.Unmarshal(catchBytes, &catch)
+	if catch.Date != "2025-08-09" {
+		t.Errorf("business date should be preserved, got %s", catch.Date)
+	}
+	if catch.RecordedAt == "" || catch.RecordedAt == catch.Date {
+		t.Errorf("RecordedAt should be the ledger timestamp, got %q", catch.RecordedAt)
+	}
+}
+
+func TestGetCatchHistory(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	stub.MockTransactionStart("tx1")
+	(&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	stub.MockTransactionEnd("tx1")
+
+	result, err := (&SmartContract{}).GetCatchHistory(ctx, "C001")
+	if err != nil {
+		t.Fatalf("GetCatchHistory failed: %v", err)
+	}
+	var history []HistoryEntry
+	if err := json.Unmarshal([]byte(result), &history); err != nil {
+		t.Fatalf("GetCatchHistory returned invalid JSON: %v", err)
+	}
+	if len(history) != 1 || history[0].TxID != "tx1" || history[0].IsDelete {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
+
+func TestGenerateReportPaged(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+
+	// Seed more catches than one page
+	for _, id := range []string{"C001", "C002", "C003"} {
+		catchBytes, _ := json.Marshal(Catch{CatchID: id, FisherID: "F001", Species: "Tilapia", WeightKg: 1.0, Date: "2025-08-09"})
+		stub.PutState("CATCH_"+id, catchBytes)
+	}
+
+	first, err := (&SmartContract{}).GenerateReportPaged(ctx, "2025-08-01", "2025-08-31", 2, "", false)
+	if err != nil {
+		t.Fatalf("GenerateReportPaged failed: %v", err)
+	}
+	if len(first.Catches) != 2 || first.Bookmark == "" {
+		t.Fatalf("expected a full first page with a bookmark, got %+v", first)
+	}
+
+	second, err := (&SmartContract{}).GenerateReportPaged(ctx, "2025-08-01", "2025-08-31", 2, first.Bookmark, false)
+	if err != nil {
+		t.Fatalf("GenerateReportPaged failed: %v", err)
+	}
+	if len(second.Catches) != 1 || second.Catches[0].CatchID != "C003" {
+		t.Errorf("expected second page to hold C003, got %+v", second.Catches)
+	}
+}
+
+func TestDeleteCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+
+	// Unauthorized access
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).DeleteCatch(ctx, "C001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can delete catches") {
+		t.Error("DeleteCatch should fail for non-authority")
+	}
+
+	// Success case
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).DeleteCatch(ctx, "C001"); err != nil {
+		t.Errorf("DeleteCatch failed: %v", err)
+	}
+	catchBytes, _ := stub.GetState("CATCH_C001")
+	if catchBytes != nil {
+		t.Error("Catch C001 should be deleted")
+	}
+	indexKey, _ := stub.CreateCompositeKey("fisher~catch", []string{"F001", "C001"})
+	indexBytes, _ := stub.GetState(indexKey)
+	if indexBytes != nil {
+		t.Error("fisher~catch index entry for C001 should be deleted")
+	}
+
+	// Non-existent catch
+	err = (&SmartContract{}).DeleteCatch(ctx, "C001")
+	if !isContractError(err, "NOT_FOUND", "catch C001 not found") {
+		t.Error("DeleteCatch should fail for non-existent catch")
+	}
+
+	// Batched catch
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C002"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	err = (&SmartContract{}).DeleteCatch(ctx, "C002")
+	if err == nil || err.Error() != "catch C002 is in batch B001; remove it from the batch before deleting" {
+		t.Errorf("DeleteCatch should refuse a batched catch, got %v", err)
+	}
+	if exists, _ := (&SmartContract{}).CatchExists(ctx, "C002"); !exists {
+		t.Error("batched catch C002 should not be deleted")
+	}
+}
+
+func TestDeleteBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	for _, id := range []string{"B001", "B002"} {
+		batchBytes, _ := json.Marshal(Batch{BatchID: id, CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+		stub.PutState("BATCH_"+id, batchBytes)
+	}
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B002", BuyerID: "BUY001", Status: "placed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	// Success case
+	if err := (&SmartContract{}).DeleteBatch(ctx, "B001"); err != nil {
+		t.Errorf("DeleteBatch failed: %v", err)
+	}
+
+	// Non-existent batch
+	err := (&SmartContract{}).DeleteBatch(ctx, "B001")
+	if !isContractError(err, "NOT_FOUND", "batch B001 not found") {
+		t.Error("DeleteBatch should fail for non-existent batch")
+	}
+
+	// Referenced by an order
+	err = (&SmartContract{}).DeleteBatch(ctx, "B002")
+	if err == nil || err.Error() != "batch B002 is referenced by an order" {
+		t.Error("DeleteBatch should fail for a batch with orders")
+	}
+}
+
+func TestValidateDate(t *testing.T) {
+	tests := []struct {
+		date  string
+		valid bool
+	}{
+		{"2025-08-09", true},
+		{"2024-02-29", true},
+		{"2025-13-40", false},
+		{"2025-02-29", false},
+		{"09/08/2025", false},
+		{"2025-8-9", false},
+		{"yesterday", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		err := validateDate(tt.date)
+		if tt.valid && err != nil {
+			t.Errorf("validateDate(%q) should succeed, got %v", tt.date, err)
+		}
+		if !tt.valid && (err == nil || err.Error() != "invalid date \""+tt.date+"\", expected YYYY-MM-DD") {
+			t.Errorf("validateDate(%q) should fail, got %v", tt.date, err)
+		}
+	}
+}
+
+func TestUpdateCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	catchBytes, _ := json.Marshal(Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	stub.PutState("CATCH_C001", catchBytes)
+
+	// Owner corrects the record
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F001")
+	if err := (&SmartContract{}).UpdateCatch(ctx, "C001", "Nile Perch", "12.0"); err != nil {
+		t.Fatalf("UpdateCatch failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.Species != "Nile Perch" || catch.WeightKg != 12.0 {
+		t.Errorf("catch not updated: %+v", catch)
+	}
+	if catch.FisherID != "F001" || catch.Date != "2025-08-09" {
+		t.Errorf("FisherID and Date should be preserved: %+v", catch)
+	}
+
+	// Invalid weight
+	err := (&SmartContract{}).UpdateCatch(ctx, "C001", "Nile Perch", "-1")
+	if err == nil || err.Error() != "weight must be positive" {
+		t.Error("UpdateCatch should fail for non-positive weight")
+	}
+
+	// Unauthorized caller
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F002")
+	err = (&SmartContract{}).UpdateCatch(ctx, "C001", "Tilapia", "5.0")
+	if !isContractError(err, "UNAUTHORIZED", "only the fisher or an authority can update a catch") {
+		t.Error("UpdateCatch should fail for another fisher")
+	}
+}
+
+func TestLogCatchBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	valid := `[
+		{"catchId": "C001", "fisherId": "F001", "species": "Tilapia", "weightKg": 10.5, "date": "2025-08-09", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5},
+		{"catchId": "C002", "fisherId": "F001", "species": "Nile Perch", "weightKg": 4.0, "date": "2025-08-10", "lat": -1.05, "lng": 33.10, "grade": "B", "storageTempC": 2.5}
+	]`
+	if err := (&SmartContract{}).LogCatchBatch(ctx, valid); err != nil {
+		t.Fatalf("LogCatchBatch failed: %v", err)
+	}
+	catches, err := listFisherCatches(ctx, "F001", false)
+	if err != nil || len(catches) != 2 {
+		t.Errorf("expected 2 catches after batch, got %d (%v)", len(catches), err)
+	}
+	for _, id := range []string{"C001", "C002"} {
+		select {
+		case event := <-stub.ChaincodeEventsChannel:
+			var payload EventPayload
+			json.Unmarshal(event.Payload, &payload)
+			if event.EventName != "CatchLogged" || payload.ID != id {
+				t.Errorf("expected CatchLogged for %s, got %s for %s", id, event.EventName, payload.ID)
+			}
+		default:
+			t.Errorf("expected CatchLogged for %s, none recorded", id)
+		}
+	}
+
+	// Contract-managed fields in the input are ignored
+	forged := `[
+		{"catchId": "C005", "fisherId": "F001", "species": "Tilapia", "weightKg": 2.0, "date": "2025-08-12", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5,
+		 "verified": true, "verifiedBy": "A001", "verifiedAt": "2025-08-12T00:00:00Z", "condemned": true, "condemnReason": "x",
+		 "archived": true, "reassignedFrom": "F999", "mspId": "EvilMSP", "seqNo": 99}
+	]`
+	if err := (&SmartContract{}).LogCatchBatch(ctx, forged); err != nil {
+		t.Fatalf("LogCatchBatch failed: %v", err)
+	}
+	catchBytes, _ := stub.GetState("CATCH_C005")
+	var stored Catch
+	json.Unmarshal(catchBytes, &stored)
+	if stored.Verified || stored.VerifiedBy != "" || stored.VerifiedAt != "" || stored.Condemned || stored.CondemnReason != "" ||
+		stored.Archived || stored.ReassignedFrom != "" || stored.MSPID == "EvilMSP" || stored.SeqNo != 3 {
+		t.Errorf("client-supplied contract fields should be ignored, got %+v", stored)
+	}
+
+	// One bad entry rejects the whole batch
+	invalid := `[
+		{"catchId": "C003", "fisherId": "F001", "species": "Tilapia", "weightKg": 3.0, "date": "2025-08-11", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5},
+		{"catchId": "C004", "fisherId": "F001", "species": "Tilapia", "weightKg": -2.0, "date": "2025-08-11", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5}
+	]`
+	err = (&SmartContract{}).LogCatchBatch(ctx, invalid)
+	if err == nil || !strings.Contains(err.Error(), "catch C004: weight must be positive") {
+		t.Errorf("LogCatchBatch should reject a negative weight, got %v", err)
+	}
+	for _, id := range []string{"C003", "C004"} {
+		if exists, _ := (&SmartContract{}).CatchExists(ctx, id); exists {
+			t.Errorf("catch %s should not have been written", id)
+		}
+	}
+}
+
+func TestSpeciesQuota(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).SetSpeciesQuota(ctx, "Tilapia", "20")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set species quotas") {
+		t.Error("SetSpeciesQuota should fail for non-authority")
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetSpeciesQuota(ctx, "Tilapia", "20"); err != nil {
+		t.Fatalf("SetSpeciesQuota failed: %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	// Under the quota
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "12.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch under quota failed: %v", err)
+	}
+
+	// Hitting the quota exactly
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "7.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch at quota failed: %v", err)
+	}
+
+	// Exceeding the quota
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "0.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "species quota exceeded for Tilapia" {
+		t.Error("LogCatch should fail once the quota is exceeded")
+	}
+
+	// Other species are unaffected
+	if err := (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Nile Perch", "50", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch for unrestricted species failed: %v", err)
+	}
+
+	// Archived catches still count against the quota
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).ArchiveCatch(ctx, "C002"); err != nil {
+		t.Fatalf("ArchiveCatch failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).LogCatch(ctx, "C005", "F001", "Tilapia", "0.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "species quota exceeded for Tilapia" {
+		t.Errorf("archived catch should still count against the quota, got %v", err)
+	}
+
+	// Condemned catches free their share of the quota
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).CondemnCatch(ctx, "C001", "spoiled"); err != nil {
+		t.Fatalf("CondemnCatch failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C005", "F001", "Tilapia", "12.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch after condemnation failed: %v", err)
+	}
+}
+
+func TestSpeciesQuotaRounding(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetSpeciesQuota(ctx, "Tilapia", "0.3"); err != nil {
+		t.Fatalf("SetSpeciesQuota failed: %v", err)
+	}
+
+	// 0.1 + 0.2 is 0.30000000000000004 in float64 and must still fit a 0.3 kg quota
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "0.1", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "0.2", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch filling the quota exactly failed: %v", err)
+	}
+}
+
+func TestGetBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C002"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	// Success case
+	batch, err := (&SmartContract{}).GetBatch(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if len(batch.CatchIDs) != 2 || batch.CatchIDs[0] != "C001" || batch.CatchIDs[1] != "C002" {
+		t.Errorf("CatchIDs did not round-trip: %v", batch.CatchIDs)
+	}
+
+	// Non-existent batch
+	_, err = (&SmartContract{}).GetBatch(ctx, "B002")
+	if !isContractError(err, "NOT_FOUND", "batch B002 not found") {
+		t.Error("GetBatch should fail for non-existent batch")
+	}
+}
+
+func TestGetAllBatchesByProcessor(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, b := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"},
+		{BatchID: "B002", CatchIDs: []string{"C002"}, ProcessorID: "P002", Date: "2025-08-09"},
+		{BatchID: "B003", CatchIDs: []string{"C003"}, ProcessorID: "P001", Date: "2025-08-10"},
+	} {
+		batchBytes, _ := json.Marshal(b)
+		stub.PutState("BATCH_"+b.BatchID, batchBytes)
+	}
+
+	batches, err := (&SmartContract{}).GetAllBatchesByProcessor(ctx, "P001", false)
+	if err != nil {
+		t.Fatalf("GetAllBatchesByProcessor failed: %v", err)
+	}
+	if len(batches) != 2 || batches[0].BatchID != "B001" || batches[1].BatchID != "B003" {
+		t.Errorf("unexpected batches for P001: %+v", batches)
+	}
+
+	// No batches
+	batches, err = (&SmartContract{}).GetAllBatchesByProcessor(ctx, "P003", false)
+	if err != nil || batches == nil || len(batches) != 0 {
+		t.Error("GetAllBatchesByProcessor should return an empty slice for an unknown processor")
+	}
+}
+
+func TestLogCatchCoordinates(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	// Valid coordinates
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.Latitude != -1.05 || catch.Longitude != 33.10 {
+		t.Errorf("coordinates not stored: %+v", catch)
+	}
+
+	// Out-of-range latitude
+	err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "91", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "latitude 91 out of range [-90, 90]" {
+		t.Errorf("LogCatch should reject latitude 91, got %v", err)
+	}
+
+	// Out-of-range longitude
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "-180.5", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "longitude -180.5 out of range [-180, 180]" {
+		t.Errorf("LogCatch should reject longitude -180.5, got %v", err)
+	}
+
+	// Records without coordinates still unmarshal
+	stub.PutState("CATCH_C004", []byte(`{"catchId":"C004","fisherId":"F001","species":"Tilapia","weightKg":1,"date":"2025-08-09"}`))
+	catch, err = (&SmartContract{}).GetCatch(ctx, "C004")
+	if err != nil || catch.Latitude != 0 || catch.Longitude != 0 {
+		t.Errorf("legacy catch should unmarshal with zero coordinates, got %+v (%v)", catch, err)
+	}
+}
+
+func TestVessels(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+
+	// Unauthorized registration
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).RegisterVessel(ctx, "V001", "Mama Victoria", "UG-1234", "F001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can register vessels") {
+		t.Error("RegisterVessel should fail for non-authority")
+	}
+
+	// Registration
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RegisterVessel(ctx, "V001", "Mama Victoria", "UG-1234", "F001"); err != nil {
+		t.Fatalf("RegisterVessel failed: %v", err)
+	}
+	vessel, err := (&SmartContract{}).GetVessel(ctx, "V001")
+	if err != nil || vessel.RegistrationNo != "UG-1234" || vessel.OwnerFisherID != "F001" {
+		t.Errorf("GetVessel returned %+v (%v)", vessel, err)
+	}
+
+	// LogCatch requires a registered vessel
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "V999", "A", "2.5", "", "")
+	if err == nil || err.Error() != "vessel V999 does not exist" {
+		t.Error("LogCatch should fail for an unknown vessel")
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "V001", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "4.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+
+	// Per-vessel query
+	catches, err := (&SmartContract{}).GetCatchesByVessel(ctx, "V001", false)
+	if err != nil || len(catches) != 1 || catches[0].CatchID != "C002" {
+		t.Errorf("GetCatchesByVessel returned %+v (%v)", catches, err)
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedFisher(stub, "F002")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).IssueLicense(ctx, "L001", "F001", "2025-01-01", "2025-06-30"); err != nil {
+		t.Fatalf("IssueLicense failed: %v", err)
+	}
+	if err := (&SmartContract{}).IssueLicense(ctx, "L002", "F002", "2025-01-01", "2025-12-31"); err != nil {
+		t.Fatalf("IssueLicense failed: %v", err)
+	}
+
+	// Blank license ID and unknown fisher
+	if err := (&SmartContract{}).IssueLicense(ctx, "", "F001", "2025-01-01", "2025-12-31"); err == nil {
+		t.Error("IssueLicense should reject an empty license ID")
+	}
+	err := (&SmartContract{}).IssueLicense(ctx, "L003", "F999", "2025-01-01", "2025-12-31")
+	if !isContractError(err, "NOT_FOUND", "fisher F999 does not exist") {
+		t.Errorf("IssueLicense should fail for an unknown fisher, got %v", err)
+	}
+	if licenseBytes, _ := stub.GetState("LICENSE_L003"); licenseBytes != nil {
+		t.Error("no license should be written for an unknown fisher")
+	}
+
+	// Valid license
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-03-01", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch with valid license failed: %v", err)
+	}
+
+	// Expired license
+	err = (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "no valid license" {
+		t.Error("LogCatch should fail after the license expired")
+	}
+
+	// Revoked license
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RevokeLicense(ctx, "L002"); err != nil {
+		t.Fatalf("RevokeLicense failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F002", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "no valid license" {
+		t.Error("LogCatch should fail with a revoked license")
+	}
+}
+
+func TestTraceOrder(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, f := range []Fisher{
+		{ID: "F001", Name: "John Doe", GovtID: "GOV123", Role: "fisher"},
+		{ID: "F002", Name: "Jane Roe", GovtID: "GOV456", Role: "fisher"},
+	} {
+		fisherBytes, _ := json.Marshal(f)
+		stub.PutPrivateData("FisherCollection", "FISHER_"+f.ID, fisherBytes)
+	}
+	for _, c := range []Catch{
+		{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"},
+		{CatchID: "C002", FisherID: "F002", Species: "Nile Perch", WeightKg: 15.0, Date: "2025-08-09"},
+	} {
+		catchBytes, _ := json.Marshal(c)
+		stub.PutState("CATCH_"+c.CatchID, catchBytes)
+	}
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C002", "C003"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY001", Status: "placed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	result, err := (&SmartContract{}).TraceOrder(ctx, "O001")
+	if err != nil {
+		t.Fatalf("TraceOrder failed: %v", err)
+	}
+	for _, want := range []string{"John Doe", "Jane Roe", "Tilapia", "Nile Perch", "catch C003 not found"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("trace should contain %q: %s", want, result)
+		}
+	}
+	if strings.Contains(result, "GOV123") {
+		t.Error("trace should not expose government IDs")
+	}
+}
+
+func TestLogCatchColdChain(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	// Valid input
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "B", "-18", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.Grade != "B" || catch.StorageTempC != -18 {
+		t.Errorf("cold-chain fields not stored: %+v", catch)
+	}
+
+	// Invalid grade
+	err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "D", "-18", "", "")
+	if err == nil || err.Error() != "invalid grade \"D\"" {
+		t.Errorf("LogCatch should reject grade D, got %v", err)
+	}
+
+	// Implausible temperature
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "55", "", "")
+	if err == nil || err.Error() != "storage temperature 55 out of range [-40, 40]" {
+		t.Errorf("LogCatch should reject 55C, got %v", err)
+	}
+
+	// Records without cold-chain fields still unmarshal
+	stub.PutState("CATCH_C004", []byte(`{"catchId":"C004","fisherId":"F001","species":"Tilapia","weightKg":1,"date":"2025-08-09"}`))
+	catch, err = (&SmartContract{}).GetCatch(ctx, "C004")
+	if err != nil || catch.Grade != "" || catch.StorageTempC != 0 {
+		t.Errorf("legacy catch should unmarshal with empty cold-chain fields, got %+v (%v)", catch, err)
+	}
+}
+
+func TestGetCatchStats(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	for _, c := range []Catch{
+		{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"},
+		{CatchID: "C002", FisherID: "F002", Species: "Nile Perch", WeightKg: 15.0, Date: "2025-08-10"},
+		{CatchID: "C003", FisherID: "F001", Species: "Tilapia", WeightKg: 4.5, Date: "2025-08-10"},
+		{CatchID: "C004", FisherID: "F001", Species: "Tilapia", WeightKg: 99.0, Date: "2025-09-01"},
+	} {
+		catchBytes, _ := json.Marshal(c)
+		stub.PutState("CATCH_"+c.CatchID, catchBytes)
+	}
+
+	result, err := (&SmartContract{}).GetCatchStats(ctx, "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GetCatchStats failed: %v", err)
+	}
+	var stats CatchStats
+	json.Unmarshal([]byte(result), &stats)
+	if stats.TotalCatches != 3 || stats.TotalWeightKg != 30.0 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	if tilapia := stats.BySpecies["Tilapia"]; tilapia == nil || tilapia.Count != 2 || tilapia.WeightKg != 15.0 {
+		t.Errorf("unexpected Tilapia stats: %+v", tilapia)
+	}
+	if perch := stats.BySpecies["Nile Perch"]; perch == nil || perch.Count != 1 || perch.WeightKg != 15.0 {
+		t.Errorf("unexpected Nile Perch stats: %+v", perch)
+	}
+
+	// Unauthorized access
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	_, err = (&SmartContract{}).GetCatchStats(ctx, "2025-08-01", "2025-08-31")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can view catch statistics") {
+		t.Error("GetCatchStats should fail for non-authority")
+	}
+}
+
+func TestFisherCatchIndex(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F002")
+	seedFisher(stub, "F002")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	(&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	(&SmartContract{}).LogCatch(ctx, "C002", "F002", "Tilapia", "3.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	(&SmartContract{}).LogCatch(ctx, "C003", "F001", "Nile Perch", "7.0", "2025-08-10", "-1.05", "33.10", "", "A", "2.5", "", "")
+
+	iterator, err := stub.GetStateByPartialCompositeKey("fisher~catch", []string{"F001"})
+	if err != nil {
+		t.Fatalf("partial composite query failed: %v", err)
+	}
+	defer iterator.Close()
+	var catchIds []string
+	for iterator.HasNext() {
+		kv, _ := iterator.Next()
+		_, parts, _ := stub.SplitCompositeKey(kv.Key)
+		catchIds = append(catchIds, parts[1])
+	}
+	if len(catchIds) != 2 || catchIds[0] != "C001" || catchIds[1] != "C003" {
+		t.Errorf("expected index entries [C001 C003] for F001, got %v", catchIds)
+	}
+}
+
+func TestGetFisherAccess(t *testing.T) {
+	stub, ctx := setupStub(t)
+	fisherBytes, _ := json.Marshal(Fisher{ID: "F001", Name: "John Doe", GovtID: "GOV123", Role: "fisher", Active: true})
+	stub.PutPrivateData("FisherCollection", "FISHER_F001", fisherBytes)
+
+	// Authority sees everything
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	fisher, err := (&SmartContract{}).GetFisher(ctx, "F001")
+	if err != nil || fisher.GovtID != "GOV123" {
+		t.Errorf("authority should see GovtID, got %+v (%v)", fisher, err)
+	}
+
+	// The fisher sees their own record
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F001")
+	fisher, err = (&SmartContract{}).GetFisher(ctx, "F001")
+	if err != nil || fisher.GovtID != "GOV123" {
+		t.Errorf("owner should see GovtID, got %+v (%v)", fisher, err)
+	}
+
+	// Third parties get a redacted record
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY001")
+	fisher, err = (&SmartContract{}).GetFisher(ctx, "F001")
+	if err != nil || fisher.GovtID != "" || fisher.Name != "John Doe" {
+		t.Errorf("third party should get a redacted record, got %+v (%v)", fisher, err)
+	}
+
+	// Public view never includes GovtID
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	fisher, err = (&SmartContract{}).GetFisherPublic(ctx, "F001")
+	if err != nil || fisher.GovtID != "" {
+		t.Errorf("GetFisherPublic should omit GovtID, got %+v (%v)", fisher, err)
+	}
+}
+
+func TestInitLedger(t *testing.T) {
+	stub, ctx := setupStub(t)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+
+	if err := (&SmartContract{}).InitLedger(ctx); err != nil {
+		t.Fatalf("InitLedger failed: %v", err)
+	}
+	catches, _ := listFisherCatches(ctx, "F001", false)
+	if len(catches) != 2 {
+		t.Fatalf("expected 2 seeded catches for F001, got %d", len(catches))
+	}
+
+	// Seeded records match what the transactions themselves would write
+	batch, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	if wantURL, _ := batchQRCodeURL(ctx, "B001"); batch.QRCodeURL != wantURL {
+		t.Errorf("expected seeded batch QR code URL %s, got %s", wantURL, batch.QRCodeURL)
+	}
+	orders, _ := (&SmartContract{}).GetOrdersByBatch(ctx, "B001", false)
+	if len(orders) != 1 || orders[0].OrderID != "O001" {
+		t.Errorf("expected seeded order O001 to be listed for B001, got %v", orders)
+	}
+
+	// Change a seeded record; a second call must not reset or duplicate it
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY001", Status: "confirmed", Date: "2025-08-12"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	if err := (&SmartContract{}).InitLedger(ctx); err != nil {
+		t.Fatalf("second InitLedger failed: %v", err)
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if order.Status != "confirmed" {
+		t.Error("second InitLedger call should be a no-op")
+	}
+	catches, _ = listFisherCatches(ctx, "F001", false)
+	if len(catches) != 2 {
+		t.Errorf("second InitLedger call should not duplicate catches, got %d", len(catches))
+	}
+
+	// The seeded fishers can log catches against the seeded licenses and species
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Tilapia", "5.0", "2025-08-13", "-0.35", "32.6", "", "A", "2", "", ""); err != nil {
+		t.Errorf("LogCatch against seeded data failed: %v", err)
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C005", "F002", "Nile Perch", "7.0", "2025-08-13", "-0.42", "33.2", "", "B", "4", "", ""); err != nil {
+		t.Errorf("LogCatch against seeded data failed: %v", err)
+	}
+}
+
+func TestGetAllCatches(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ids := []string{"C001", "C002", "C003", "C004", "C005"}
+	for _, id := range ids {
+		seedCatch(stub, Catch{CatchID: id, FisherID: "F001", Species: "Tilapia", WeightKg: 1.0, Date: "2025-08-09"})
+	}
+
+	// Walk every page and make sure each catch is seen exactly once
+	seen := map[string]int{}
+	bookmark := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := (&SmartContract{}).GetAllCatches(ctx, 2, bookmark, false)
+		if err != nil {
+			t.Fatalf("GetAllCatches failed: %v", err)
+		}
+		for _, c := range page.Catches {
+			seen[c.CatchID]++
+		}
+		if page.Bookmark == "" || len(page.Catches) < 2 {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	if len(seen) != len(ids) {
+		t.Errorf("expected %d distinct catches, got %d", len(ids), len(seen))
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("catch %s returned %d times", id, count)
+		}
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C002", "C003"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	// Clean split
+	if err := (&SmartContract{}).SplitBatch(ctx, "B001", "B002", []string{"C002"}); err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	parent, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	child, _ := (&SmartContract{}).GetBatch(ctx, "B002")
+	if len(parent.CatchIDs) != 2 || parent.CatchIDs[0] != "C001" || parent.CatchIDs[1] != "C003" {
+		t.Errorf("parent should keep C001 and C003, got %v", parent.CatchIDs)
+	}
+	if len(child.CatchIDs) != 1 || child.CatchIDs[0] != "C002" || child.ParentBatchID != "B001" || child.ProcessorID != "P001" {
+		t.Errorf("unexpected child batch %+v", child)
+	}
+
+	// Catch not in the parent
+	err := (&SmartContract{}).SplitBatch(ctx, "B001", "B003", []string{"C002"})
+	if err == nil || err.Error() != "catch C002 is not in batch B001" {
+		t.Errorf("SplitBatch should reject a catch outside the parent, got %v", err)
+	}
+	parent, _ = (&SmartContract{}).GetBatch(ctx, "B001")
+	if len(parent.CatchIDs) != 2 {
+		t.Error("a rejected split must not modify the parent")
+	}
+
+	// A child split off a held batch stays held
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).HoldBatch(ctx, "B001", "histamine test"); err != nil {
+		t.Fatalf("HoldBatch failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).SplitBatch(ctx, "B001", "B003", []string{"C003"}); err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	child, _ = (&SmartContract{}).GetBatch(ctx, "B003")
+	if !child.OnHold || child.HoldReason != "histamine test" {
+		t.Errorf("child of a held batch should inherit the hold, got %+v", child)
+	}
+}
+
+func TestMergeBatches(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	for _, b := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001", "C002"}, ProcessorID: "P001", Date: "2025-08-09"},
+		{BatchID: "B002", CatchIDs: []string{"C002", "C003"}, ProcessorID: "P001", Date: "2025-08-09"},
+		{BatchID: "B003", CatchIDs: []string{"C004"}, ProcessorID: "P001", Date: "2025-08-10"},
+		{BatchID: "B004", CatchIDs: []string{"C005"}, ProcessorID: "P002", Date: "2025-08-10"},
+	} {
+		batchBytes, _ := json.Marshal(b)
+		stub.PutState("BATCH_"+b.BatchID, batchBytes)
+	}
+
+	// Successful merge with an overlapping catch
+	if err := (&SmartContract{}).MergeBatches(ctx, "B001", []string{"B002", "B003"}); err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	target, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	want := []string{"C001", "C002", "C003", "C004"}
+	if len(target.CatchIDs) != len(want) {
+		t.Fatalf("expected deduplicated catches %v, got %v", want, target.CatchIDs)
+	}
+	for i := range want {
+		if target.CatchIDs[i] != want[i] {
+			t.Errorf("expected deduplicated catches %v, got %v", want, target.CatchIDs)
+		}
+	}
+	if len(target.MergedFrom) != 2 || target.MergedFrom[0] != "B002" || target.MergedFrom[1] != "B003" {
+		t.Errorf("unexpected merge lineage %v", target.MergedFrom)
+	}
+	if exists, _ := (&SmartContract{}).BatchExists(ctx, "B002"); exists {
+		t.Error("source batch B002 should be deleted")
+	}
+
+	// Source from a different processor
+	err := (&SmartContract{}).MergeBatches(ctx, "B001", []string{"B004"})
+	if err == nil || err.Error() != "batch B004 belongs to processor P002, not P001" {
+		t.Errorf("MergeBatches should reject another processor's batch, got %v", err)
+	}
+
+	// Held source
+	heldBytes, _ := json.Marshal(Batch{BatchID: "B005", CatchIDs: []string{"C006"}, ProcessorID: "P001", Date: "2025-08-10", OnHold: true, HoldReason: "histamine test"})
+	stub.PutState("BATCH_B005", heldBytes)
+	err = (&SmartContract{}).MergeBatches(ctx, "B001", []string{"B005"})
+	if err == nil || err.Error() != "batch B005 is on hold: histamine test" {
+		t.Errorf("MergeBatches should reject a held source, got %v", err)
+	}
+	if exists, _ := (&SmartContract{}).BatchExists(ctx, "B005"); !exists {
+		t.Error("held batch B005 should not be merged away")
+	}
+}
+
+func TestSplitMergeDeclaredWeight(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	sc := &SmartContract{}
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 30, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F001", Species: "Tilapia", WeightKg: 5, Date: "2025-08-09"})
+	for _, b := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001", "C002"}, ProcessorID: "P001", Date: "2025-08-09", DeclaredWeightKg: 36},
+		{BatchID: "B003", CatchIDs: []string{"C003"}, ProcessorID: "P001", Date: "2025-08-09"},
+	} {
+		batchBytes, _ := json.Marshal(b)
+		stub.PutState("BATCH_"+b.BatchID, batchBytes)
+	}
+
+	// Split shares the declared weight by catch weight: 10 of 40 kg moves
+	if err := sc.SplitBatch(ctx, "B001", "B002", []string{"C002"}); err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	parent, _ := sc.GetBatch(ctx, "B001")
+	child, _ := sc.GetBatch(ctx, "B002")
+	if parent.DeclaredWeightKg != 27 || child.DeclaredWeightKg != 9 {
+		t.Errorf("expected 27 and 9 kg declared, got %v and %v", parent.DeclaredWeightKg, child.DeclaredWeightKg)
+	}
+
+	// Merging declared batches sums their declared weights
+	if err := sc.MergeBatches(ctx, "B001", []string{"B002"}); err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	target, _ := sc.GetBatch(ctx, "B001")
+	if target.DeclaredWeightKg != 36 {
+		t.Errorf("expected 36 kg declared after merge, got %v", target.DeclaredWeightKg)
+	}
+
+	// Merging in an undeclared batch clears it
+	if err := sc.MergeBatches(ctx, "B001", []string{"B003"}); err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+	target, _ = sc.GetBatch(ctx, "B001")
+	if target.DeclaredWeightKg != 0 {
+		t.Errorf("expected declared weight cleared after merging an undeclared batch, got %v", target.DeclaredWeightKg)
+	}
+}
+
+func TestEndangeredSpecies(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Lungfish")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetEndangeredSpecies(ctx, "Lungfish"); err != nil {
+		t.Fatalf("SetEndangeredSpecies failed: %v", err)
+	}
+
+	// Allowed species
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch for allowed species failed: %v", err)
+	}
+
+	// Listed species
+	err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Lungfish", "3.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "species Lungfish is protected and cannot be logged" {
+		t.Errorf("LogCatch should reject a protected species, got %v", err)
+	}
+
+	// After removal
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RemoveEndangeredSpecies(ctx, "Lungfish"); err != nil {
+		t.Fatalf("RemoveEndangeredSpecies failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Lungfish", "3.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch after delisting failed: %v", err)
+	}
+}
+
+func TestContractError(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+
+	err := (&SmartContract{}).RegisterFisher(ctx, "F001", "John Doe", "GOV123")
+	var contractErr *ContractError
+	if !errors.As(err, &contractErr) {
+		t.Fatalf("expected a ContractError, got %T", err)
+	}
+	if contractErr.Code != CodeUnauthorized || contractErr.Message != "only authority can register fishers" {
+		t.Errorf("unexpected error %+v", contractErr)
+	}
+	if err.Error() != "UNAUTHORIZED: only authority can register fishers" {
+		t.Errorf("unexpected error text %q", err.Error())
+	}
+
+	_, err = (&SmartContract{}).GetOrder(ctx, "O404")
+	if !isContractError(err, CodeNotFound, "order O404 not found") {
+		t.Errorf("expected NOT_FOUND for a missing order, got %v", err)
+	}
+}
+
+func TestFisherActivation(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).DeactivateFisher(ctx, "F001"); err != nil {
+		t.Fatalf("DeactivateFisher failed: %v", err)
+	}
+
+	// Deactivation blocks catches
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "fisher F001 is not active" {
+		t.Errorf("LogCatch should fail for an inactive fisher, got %v", err)
+	}
+
+	// Only authority may reactivate
+	err = (&SmartContract{}).ReactivateFisher(ctx, "F001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can reactivate fishers") {
+		t.Errorf("ReactivateFisher should fail for non-authority, got %v", err)
+	}
+
+	// Reactivation restores logging
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).ReactivateFisher(ctx, "F001"); err != nil {
+		t.Fatalf("ReactivateFisher failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch after reactivation failed: %v", err)
+	}
+
+	// Records registered before the Active flag existed stay active
+	stub.PutPrivateData("FisherCollection", "FISHER_F002", []byte(`{"id":"F002","name":"Jane Roe","govtId":"GOV456","role":"fisher"}`))
+	fisher, _ := (&SmartContract{}).GetFisherPublic(ctx, "F002")
+	if !fisher.Active {
+		t.Error("legacy fisher records should default to active")
+	}
+}
+
+func TestQRBaseURL(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+
+	// Default base
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001"}, "P001", "2025-08-09", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	batch, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	if batch.QRCodeURL != "https://getreech.example.org/batch/B001" {
+		t.Errorf("unexpected default QR URL %s", batch.QRCodeURL)
+	}
+
+	// Custom base
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetQRBaseURL(ctx, "https://trace.lakefish.ug/b/"); err != nil {
+		t.Fatalf("SetQRBaseURL failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C001"}, "P001", "2025-08-09", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	batch, _ = (&SmartContract{}).GetBatch(ctx, "B002")
+	if batch.QRCodeURL != "https://trace.lakefish.ug/b/B002" {
+		t.Errorf("unexpected custom QR URL %s", batch.QRCodeURL)
+	}
+
+	// Malformed URL
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	err := (&SmartContract{}).SetQRBaseURL(ctx, "not a url")
+	if err == nil || err.Error() != "invalid QR base URL \"not a url\"" {
+		t.Errorf("SetQRBaseURL should reject a malformed URL, got %v", err)
+	}
+}
+
+func TestTransferFisherRegion(t *testing.T) {
+	stub, ctx := setupStub(t)
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	for _, f := range []Fisher{
+		{ID: "F001", Name: "John Doe", GovtID: "GOV123", Role: "fisher", Region: "Kampala", Active: true},
+		{ID: "F002", Name: "Jane Roe", GovtID: "GOV456", Role: "fisher", Region: "Kampala", Active: true},
+	} {
+		fisherBytes, _ := json.Marshal(f)
+		stub.PutPrivateData("FisherCollection", "FISHER_"+f.ID, fisherBytes)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).TransferFisherRegion(ctx, "F001", "Jinja"); err != nil {
+		t.Fatalf("TransferFisherRegion failed: %v", err)
+	}
+
+	// Region updated, other fields preserved
+	fisher, _ := (&SmartContract{}).GetFisher(ctx, "F001")
+	if fisher.Region != "Jinja" || fisher.Name != "John Doe" || fisher.GovtID != "GOV123" || !fisher.Active {
+		t.Errorf("unexpected fisher after transfer: %+v", fisher)
+	}
+
+	// Event fired
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		if event.EventName != "FisherRegionTransferred" {
+			t.Errorf("expected FisherRegionTransferred, got %s", event.EventName)
+		}
+	default:
+		t.Error("expected FisherRegionTransferred event")
+	}
+
+	// Region query
+	fishers, err := (&SmartContract{}).GetFishersByRegion(ctx, "Jinja")
+	if err != nil || len(fishers) != 1 || fishers[0].ID != "F001" {
+		t.Errorf("GetFishersByRegion(Jinja) returned %+v (%v)", fishers, err)
+	}
+	fishers, err = (&SmartContract{}).GetFishersByRegion(ctx, "Kampala")
+	if err != nil || len(fishers) != 1 || fishers[0].ID != "F002" {
+		t.Errorf("GetFishersByRegion(Kampala) returned %+v (%v)", fishers, err)
+	}
+}
+
+func TestDeleteFisher(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedFisher(stub, "F001")
+	seedFisher(stub, "F002")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F002", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).DeleteFisher(ctx, "F001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can delete fishers") {
+		t.Errorf("DeleteFisher should fail for non-authority, got %v", err)
+	}
+
+	// Unreferenced fisher is removed
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).DeleteFisher(ctx, "F001"); err != nil {
+		t.Fatalf("DeleteFisher failed: %v", err)
+	}
+	if _, err := (&SmartContract{}).GetFisher(ctx, "F001"); !isContractError(err, "NOT_FOUND", "fisher F001 does not exist") {
+		t.Errorf("fisher F001 should be gone, got %v", err)
+	}
+
+	// Fisher with catches is kept
+	err = (&SmartContract{}).DeleteFisher(ctx, "F002")
+	if err == nil || err.Error() != "cannot delete fisher with existing catches" {
+		t.Errorf("DeleteFisher should refuse a fisher with catches, got %v", err)
+	}
+	if _, err := (&SmartContract{}).GetFisher(ctx, "F002"); err != nil {
+		t.Errorf("fisher F002 should remain: %v", err)
+	}
+}
+
+func TestGetOrdersByBuyerAndBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B002", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+		{OrderID: "O003", BatchID: "B001", BuyerID: "BUY2", Status: "placed", Date: "2025-08-11"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+
+	byBuyer, err := (&SmartContract{}).GetOrdersByBuyer(ctx, "BUY1", false)
+	if err != nil || len(byBuyer) != 2 || byBuyer[0].OrderID != "O001" || byBuyer[1].OrderID != "O002" {
+		t.Errorf("expected O001 and O002 for BUY1, got %v (%v)", byBuyer, err)
+	}
+	byBuyer, _ = (&SmartContract{}).GetOrdersByBuyer(ctx, "BUY2", false)
+	if len(byBuyer) != 1 || byBuyer[0].OrderID != "O003" {
+		t.Errorf("expected O003 for BUY2, got %v", byBuyer)
+	}
+
+	byBatch, err := (&SmartContract{}).GetOrdersByBatch(ctx, "B001", false)
+	if err != nil || len(byBatch) != 2 || byBatch[0].OrderID != "O001" || byBatch[1].OrderID != "O003" {
+		t.Errorf("expected O001 and O003 for B001, got %v (%v)", byBatch, err)
+	}
+	byBatch, _ = (&SmartContract{}).GetOrdersByBatch(ctx, "B002", false)
+	if len(byBatch) != 1 || byBatch[0].OrderID != "O002" {
+		t.Errorf("expected O002 for B002, got %v", byBatch)
+	}
+
+	// No match yields an empty slice
+	none, err := (&SmartContract{}).GetOrdersByBuyer(ctx, "BUY3", false)
+	if err != nil || none == nil || len(none) != 0 {
+		t.Errorf("expected empty slice for unknown buyer, got %v (%v)", none, err)
+	}
+}
+
+func TestLogCatchWeightUnit(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	// Pounds are stored as kilograms, rounded to the gram
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "lb", ""); err != nil {
+		t.Fatalf("LogCatch in pounds failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if want := roundWeightKg(10 * 0.45359237); catch.WeightKg != want {
+		t.Errorf("expected %v kg, got %v", want, catch.WeightKg)
+	}
+
+	// Explicit kg is unchanged
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "kg", ""); err != nil {
+		t.Fatalf("LogCatch in kilograms failed: %v", err)
+	}
+	catch, _ = (&SmartContract{}).GetCatch(ctx, "C002")
+	if catch.WeightKg != 10 {
+		t.Errorf("expected 10 kg, got %v", catch.WeightKg)
+	}
+
+	err := (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "10", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "stone", "")
+	if err == nil || err.Error() != `unknown weight unit "stone", expected kg or lb` {
+		t.Errorf("LogCatch should reject an unknown unit, got %v", err)
+	}
+}
+
+func TestGetLedgerSummary(t *testing.T) {
+	stub, ctx := setupStub(t)
+
+	// Empty ledger
+	result, err := (&SmartContract{}).GetLedgerSummary(ctx)
+	if err != nil || result != `{"fishers":0,"catches":0,"batches":0,"orders":0}` {
+		t.Errorf("unexpected empty summary %s (%v)", result, err)
+	}
+
+	seedFisher(stub, "F001")
+	seedFisher(stub, "F002")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F002", Species: "Nile Perch", WeightKg: 7.0, Date: "2025-08-10"})
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C002"}, ProcessorID: "P001", Date: "2025-08-10"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	var summary LedgerSummary
+	result, err = (&SmartContract{}).GetLedgerSummary(ctx)
+	if err != nil {
+		t.Fatalf("GetLedgerSummary failed: %v", err)
+	}
+	json.Unmarshal([]byte(result), &summary)
+	if summary != (LedgerSummary{Fishers: 2, Catches: 3, Batches: 1, Orders: 0}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B001", BuyerID: "BUY1", Status: "shipped", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+
+	// Unrelated caller
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY2")
+	err := (&SmartContract{}).CancelOrder(ctx, "O001")
+	if !isContractError(err, "UNAUTHORIZED", "only the buyer or an authority can cancel an order") {
+		t.Errorf("CancelOrder should fail for another buyer, got %v", err)
+	}
+
+	// Ordering buyer cancels
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY1")
+	stub.MockTransactionStart("tx1")
+	defer stub.MockTransactionEnd("tx1")
+	if err := (&SmartContract{}).CancelOrder(ctx, "O001"); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if order.Status != "cancelled" {
+		t.Errorf("expected status cancelled, got %s", order.Status)
+	}
+	select {
+	case event := <-stub.ChaincodeEventsChannel:
+		if event.EventName != "OrderCancelled" {
+			t.Errorf("expected OrderCancelled event, got %s", event.EventName)
+		}
+	default:
+		t.Error("expected OrderCancelled event, none recorded")
+	}
+
+	// Shipped orders can't be cancelled
+	err = (&SmartContract{}).CancelOrder(ctx, "O002")
+	if err == nil || err.Error() != "cannot cancel order O002 with status shipped" {
+		t.Errorf("CancelOrder should fail after shipping, got %v", err)
+	}
+}
+
+func TestPriceQuote(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	err := (&SmartContract{}).SubmitPriceQuote(ctx, "B001", "4.75")
+	if !isContractError(err, "UNAUTHORIZED", "only processor can submit price quotes") {
+		t.Errorf("SubmitPriceQuote should fail for non-processor, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	err = (&SmartContract{}).SubmitPriceQuote(ctx, "B001", "0")
+	if err == nil || err.Error() != "price must be positive" {
+		t.Errorf("SubmitPriceQuote should reject a zero price, got %v", err)
+	}
+	if err := (&SmartContract{}).SubmitPriceQuote(ctx, "B001", "4.75"); err != nil {
+		t.Fatalf("SubmitPriceQuote failed: %v", err)
+	}
+
+	// Stored privately, not in world state
+	if quoteBytes, _ := stub.GetState("PRICE_B001"); quoteBytes != nil {
+		t.Error("price quote should not be in world state")
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	quote, err := (&SmartContract{}).GetPriceQuote(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetPriceQuote failed: %v", err)
+	}
+	if quote.Price != 4.75 || quote.ProcessorID != "P001" {
+		t.Errorf("unexpected quote %+v", quote)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	_, err = (&SmartContract{}).GetPriceQuote(ctx, "B001")
+	if !isContractError(err, "UNAUTHORIZED", "only processor or buyer can read price quotes") {
+		t.Errorf("GetPriceQuote should fail for fisher, got %v", err)
+	}
+}
+
+func TestRejectEmptyIDs(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	sc := &SmartContract{}
+	tests := []struct {
+		name  string
+		role  string
+		call  func() error
+		field string
+	}{
+		{"RegisterFisher id", "authority", func() error { return sc.RegisterFisher(ctx, "", "John Doe", "GOV123") }, "id"},
+		{"LogCatch catchId", "fisher", func() error {
+			return sc.LogCatch(ctx, "", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+		}, "catchId"},
+		{"LogCatch fisherId", "fisher", func() error {
+			return sc.LogCatch(ctx, "C002", "", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+		}, "fisherId"},
+		{"CreateBatch batchId", "processor", func() error { return sc.CreateBatch(ctx, "", []string{"C001"}, "P001", "2025-08-09", "", "", "") }, "batchId"},
+		{"CreateBatch processorId", "processor", func() error { return sc.CreateBatch(ctx, "B002", []string{"C001"}, "", "2025-08-09", "", "", "") }, "processorId"},
+		{"PlaceOrder orderId", "buyer", func() error { return sc.PlaceOrder(ctx, "", "B001", "BUY001", "2025-08-10", "250", "USD", "", "") }, "orderId"},
+		{"PlaceOrder batchId", "buyer", func() error { return sc.PlaceOrder(ctx, "O001", "", "BUY001", "2025-08-10", "250", "USD", "", "") }, "batchId"},
+		{"PlaceOrder buyerId", "buyer", func() error { return sc.PlaceOrder(ctx, "O001", "B001", "", "2025-08-10", "250", "USD", "", "") }, "buyerId"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx.GetClientIdentity().SetAttributeValue("hf.Role", tc.role)
+			err := tc.call()
+			if err == nil || err.Error() != "field "+tc.field+" must not be empty" {
+				t.Errorf("expected empty %s to be rejected, got %v", tc.field, err)
+			}
+		})
+	}
+
+	for _, key := range []string{"FISHER_", "CATCH_", "BATCH_", "ORDER_"} {
+		if value, _ := stub.GetState(key); value != nil {
+			t.Errorf("bare key %s should not have been written", key)
+		}
+	}
+}
+
+func TestLogCatchDuplicate(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F001")
+
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+
+	// Same ID again is refused and the original is kept
+	err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Nile Perch", "3.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "catch C001 already exists" {
+		t.Errorf("duplicate LogCatch should fail, got %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.Species != "Tilapia" || catch.WeightKg != 10.5 {
+		t.Errorf("original catch was overwritten: %+v", catch)
+	}
+
+	// Intentional edits go through UpdateCatch
+	if err := (&SmartContract{}).UpdateCatch(ctx, "C001", "Nile Perch", "3.0"); err != nil {
+		t.Fatalf("UpdateCatch failed: %v", err)
+	}
+	catch, _ = (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.Species != "Nile Perch" || catch.WeightKg != 3.0 {
+		t.Errorf("catch not updated: %+v", catch)
+	}
+}
+
+func TestGetBatchWithCatches(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 4.0, Date: "2025-08-09"})
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001", "C002", "C404"}, ProcessorID: "P001", Date: "2025-08-10"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	result, err := (&SmartContract{}).GetBatchWithCatches(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatchWithCatches failed: %v", err)
+	}
+	var expanded BatchWithCatches
+	if err := json.Unmarshal([]byte(result), &expanded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if expanded.Batch == nil || expanded.Batch.BatchID != "B001" {
+		t.Errorf("expected batch B001, got %+v", expanded.Batch)
+	}
+	if len(expanded.Catches) != 2 || expanded.Catches[0].Species != "Tilapia" || expanded.Catches[1].WeightKg != 4.0 {
+		t.Errorf("expected C001 and C002 inlined, got %+v", expanded.Catches)
+	}
+	if len(expanded.Missing) != 1 || expanded.Missing[0] != "C404" {
+		t.Errorf("expected C404 missing, got %v", expanded.Missing)
+	}
+
+	_, err = (&SmartContract{}).GetBatchWithCatches(ctx, "B002")
+	if !isContractError(err, "NOT_FOUND", "batch B002 not found") {
+		t.Errorf("GetBatchWithCatches should fail for a missing batch, got %v", err)
+	}
+}
+
+func TestGenerateSalesReport(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 20, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F001", Species: "Nile Perch", WeightKg: 30, Date: "2025-08-02"})
+	for _, batch := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-03"},
+		{BatchID: "B002", CatchIDs: []string{"C002", "C003"}, ProcessorID: "P001", Date: "2025-08-03"},
+		{BatchID: "B003", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-03"},
+	} {
+		batchBytes, _ := json.Marshal(batch)
+		stub.PutState("BATCH_"+batch.BatchID, batchBytes)
+	}
+	for _, quote := range []PriceQuote{{BatchID: "B001", ProcessorID: "P001", Price: 2}, {BatchID: "B002", ProcessorID: "P001", Price: 5}} {
+		quoteBytes, _ := json.Marshal(quote)
+		stub.PutPrivateData("PriceCollection", "PRICE_"+quote.BatchID, quoteBytes)
+	}
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "delivered", Date: "2025-08-10", Amount: 20, Currency: "USD"},
+		{OrderID: "O002", BatchID: "B002", BuyerID: "BUY2", Status: "delivered", Date: "2025-08-12", Amount: 250, Currency: "USD"},
+		{OrderID: "O003", BatchID: "B003", BuyerID: "BUY1", Status: "delivered", Date: "2025-08-12", Amount: 90, Currency: "KES"},
+		{OrderID: "O004", BatchID: "B001", BuyerID: "BUY1", Status: "shipped", Date: "2025-08-12"},
+		{OrderID: "O005", BatchID: "B001", BuyerID: "BUY1", Status: "delivered", Date: "2025-09-01"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	_, err := (&SmartContract{}).GenerateSalesReport(ctx, "2025-08-01", "2025-08-31")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can generate sales reports") {
+		t.Errorf("GenerateSalesReport should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	result, err := (&SmartContract{}).GenerateSalesReport(ctx, "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GenerateSalesReport failed: %v", err)
+	}
+	var report SalesReport
+	json.Unmarshal([]byte(result), &report)
+
+	// 10 kg at 2 and 50 kg at 5: (20 + 250) / 60 = 4.5; O003 has no price quote
+	if report.Orders != 2 || report.TotalVolumeKg != 60 || report.AveragePrice != 4.5 || report.Skipped != 1 {
+		t.Errorf("unexpected sales report %+v", report)
+	}
+	if report.RevenueByCurrency["USD"] != 270 || report.RevenueByCurrency["KES"] != 90 {
+		t.Errorf("unexpected revenue by currency %v", report.RevenueByCurrency)
+	}
+}
+
+func TestResolveRole(t *testing.T) {
+	// Only the Fabric CA attribute is set
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if !(&SmartContract{}).hasRole(ctx, "authority") {
+		t.Error("hf.Role should be recognized when role is absent")
+	}
+
+	// The custom attribute takes precedence
+	_, ctx = setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	ctx.GetClientIdentity().SetAttributeValue("role", "buyer")
+	if role, _ := resolveRole(ctx); role != "buyer" {
+		t.Errorf("expected role buyer, got %s", role)
+	}
+	if (&SmartContract{}).hasRole(ctx, "authority") {
+		t.Error("hf.Role should be ignored when role is set")
+	}
+
+	// Neither attribute set
+	_, ctx = setupStub(t)
+	if (&SmartContract{}).hasRole(ctx, "authority") {
+		t.Error("caller without a role attribute should have no role")
+	}
+}
+
+func TestMultiRole(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("roles", "processor, buyer")
+
+	sc := &SmartContract{}
+	if !sc.hasRole(ctx, "processor") || !sc.hasRole(ctx, "buyer") {
+		t.Error("caller with roles=processor,buyer should pass both checks")
+	}
+	if sc.hasRole(ctx, "authority") {
+		t.Error("caller with roles=processor,buyer should not be an authority")
+	}
+
+	// The single-value attribute still works alongside the list
+	ctx.GetClientIdentity().SetAttributeValue("role", "fisher")
+	if !sc.hasRole(ctx, "fisher") || !sc.hasRole(ctx, "buyer") {
+		t.Error("role and roles attributes should both be honoured")
+	}
+}
+
+func TestVerifyCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).VerifyCatch(ctx, "C001")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can verify catches") {
+		t.Errorf("VerifyCatch should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "AUTH1")
+	if err := (&SmartContract{}).VerifyCatch(ctx, "C001"); err != nil {
+		t.Fatalf("VerifyCatch failed: %v", err)
+	}
+
+	// Fields persist on the stored record
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if !catch.Verified || catch.VerifiedBy != "AUTH1" || catch.VerifiedAt == "" {
+		t.Errorf("verification not recorded: %+v", catch)
+	}
+
+	// Reports count verified catches
+	result, _ := (&SmartContract{}).GetCatchStats(ctx, "2025-08-01", "2025-08-31")
+	var stats CatchStats
+	json.Unmarshal([]byte(result), &stats)
+	if stats.TotalCatches != 2 || stats.VerifiedCatches != 1 {
+		t.Errorf("expected 1 of 2 catches verified, got %+v", stats)
+	}
+
+	if err := (&SmartContract{}).VerifyCatch(ctx, "C404"); !isContractError(err, "NOT_FOUND", "catch C404 not found") {
+		t.Errorf("VerifyCatch should fail for a missing catch, got %v", err)
+	}
+}
+
+func TestRegionSpecies(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch", "Lungfish")
+	for _, fisher := range []Fisher{
+		{ID: "F001", Name: "John Doe", GovtID: "GOV123", Role: "fisher", Region: "Lake Victoria", Active: true},
+		{ID: "F002", Name: "Jane Roe", GovtID: "GOV456", Role: "fisher", Region: "Lake Turkana", Active: true},
+	} {
+		fisherBytes, _ := json.Marshal(fisher)
+		stub.PutPrivateData("FisherCollection", "FISHER_"+fisher.ID, fisherBytes)
+		seedLicense(stub, fisher.ID)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).SetRegionSpecies(ctx, "Lake Victoria", []string{"Tilapia"})
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set region species") {
+		t.Errorf("SetRegionSpecies should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetRegionSpecies(ctx, "Lake Victoria", []string{"Tilapia", "Nile Perch"}); err != nil {
+		t.Fatalf("SetRegionSpecies failed: %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	// Permitted species
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Nile Perch", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch for a permitted species failed: %v", err)
+	}
+
+	// Species not on the region's list
+	err = (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Lungfish", "3.0", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "species Lungfish not permitted in region Lake Victoria" {
+		t.Errorf("LogCatch should reject a non-permitted species, got %v", err)
+	}
+
+	// Region without a list allows anything
+	if err := (&SmartContract{}).LogCatch(ctx, "C003", "F002", "Lungfish", "3.0", "2025-08-09", "3.60", "36.00", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch in an unconfigured region failed: %v", err)
+	}
+}
+
+func TestGenerateReportNonASCIIID(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+
+	// "É" is encoded as bytes above '~', which a "CATCH_~" end key would exclude
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "ÉC002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+
+	result, err := (&SmartContract{}).GenerateReport(ctx, "2025-08-01", "2025-08-31", false)
+	if err != nil {
+		t.Fatalf("GenerateReport failed: %v", err)
+	}
+	var catches []Catch
+	json.Unmarshal([]byte(result), &catches)
+	if len(catches) != 2 || catches[1].CatchID != "ÉC002" {
+		t.Errorf("expected both catches including ÉC002, got %+v", catches)
+	}
+}
+
+func TestShipments(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "confirmed", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	err := (&SmartContract{}).CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-11")
+	if !isContractError(err, "UNAUTHORIZED", "only processor can create shipments") {
+		t.Errorf("CreateShipment should fail for non-processor, got %v", err)
+	}
+
+	// Confirmed order ships and the order follows
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-11"); err != nil {
+		t.Fatalf("CreateShipment failed: %v", err)
+	}
+	shipment, err := (&SmartContract{}).GetShipment(ctx, "S001")
+	if err != nil || shipment.OrderID != "O001" || shipment.Status != "in_transit" {
+		t.Errorf("unexpected shipment %+v (%v)", shipment, err)
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if order.Status != "shipped" {
+		t.Errorf("expected order O001 shipped, got %s", order.Status)
+	}
+
+	// Placed orders must be confirmed first
+	err = (&SmartContract{}).CreateShipment(ctx, "S002", "O002", "LakeFreight", "TRK-2", "2025-08-11")
+	if err == nil || err.Error() != "order O002 must be confirmed before shipping, status is placed" {
+		t.Errorf("CreateShipment should reject a placed order, got %v", err)
+	}
+	order, _ = (&SmartContract{}).GetOrder(ctx, "O002")
+	if order.Status != "placed" {
+		t.Errorf("order O002 should stay placed, got %s", order.Status)
+	}
+
+	if err := (&SmartContract{}).UpdateShipmentStatus(ctx, "S001", "delivered"); err != nil {
+		t.Fatalf("UpdateShipmentStatus failed: %v", err)
+	}
+	shipment, _ = (&SmartContract{}).GetShipment(ctx, "S001")
+	if shipment.Status != "delivered" {
+		t.Errorf("expected shipment delivered, got %s", shipment.Status)
+	}
+	err = (&SmartContract{}).UpdateShipmentStatus(ctx, "S001", "lost-at-sea")
+	if err == nil || err.Error() != `unknown shipment status "lost-at-sea"` {
+		t.Errorf("UpdateShipmentStatus should reject an unknown status, got %v", err)
+	}
+}
+
+func TestGetBatchForCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, id := range []string{"C001", "C002", "C003"} {
+		seedCatch(stub, Catch{CatchID: id, FisherID: "F001", Species: "Tilapia", WeightKg: 5, Date: "2025-08-09"})
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001", "C002"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	for _, id := range []string{"C001", "C002"} {
+		if batchId, err := (&SmartContract{}).GetBatchForCatch(ctx, id); err != nil || batchId != "B001" {
+			t.Errorf("expected %s in B001, got %q (%v)", id, batchId, err)
+		}
+	}
+
+	_, err := (&SmartContract{}).GetBatchForCatch(ctx, "C003")
+	if !isContractError(err, "NOT_FOUND", "catch C003 not assigned to any batch") {
+		t.Errorf("GetBatchForCatch should fail for an unbatched catch, got %v", err)
+	}
+
+	// After a split the moved catch points at the child
+	if err := (&SmartContract{}).SplitBatch(ctx, "B001", "B002", []string{"C002"}); err != nil {
+		t.Fatalf("SplitBatch failed: %v", err)
+	}
+	if batchId, _ := (&SmartContract{}).GetBatchForCatch(ctx, "C002"); batchId != "B002" {
+		t.Errorf("expected C002 in B002 after split, got %q", batchId)
+	}
+	if batchId, _ := (&SmartContract{}).GetBatchForCatch(ctx, "C001"); batchId != "B001" {
+		t.Errorf("expected C001 to stay in B001, got %q", batchId)
+	}
+}
+
+func TestWeightRounding(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.3335", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if catch.WeightKg != 10.334 {
+		t.Errorf("expected 10.334 kg, got %v", catch.WeightKg)
+	}
+
+	// Batch entries are rounded the same way
+	batch := `[
+		{"catchId": "C002", "fisherId": "F001", "species": "Tilapia", "weightKg": 0.1, "date": "2025-08-09", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5},
+		{"catchId": "C003", "fisherId": "F001", "species": "Tilapia", "weightKg": 0.2000004, "date": "2025-08-09", "lat": -1.05, "lng": 33.10, "grade": "A", "storageTempC": 2.5}
+	]`
+	if err := (&SmartContract{}).LogCatchBatch(ctx, batch); err != nil {
+		t.Fatalf("LogCatchBatch failed: %v", err)
+	}
+	catch, _ = (&SmartContract{}).GetCatch(ctx, "C003")
+	if catch.WeightKg != 0.2 {
+		t.Errorf("expected 0.2 kg, got %v", catch.WeightKg)
+	}
+
+	// 10.334 + 0.1 + 0.2 sums without float noise
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	result, _ := (&SmartContract{}).GetCatchStats(ctx, "2025-08-01", "2025-08-31")
+	var stats CatchStats
+	json.Unmarshal([]byte(result), &stats)
+	if stats.TotalWeightKg != 10.634 || stats.BySpecies["Tilapia"].WeightKg != 10.634 {
+		t.Errorf("expected 10.634 kg total, got %+v", stats)
+	}
+}
+
+func TestGetFisherCatchSummary(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 4.0, Date: "2025-07-30"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F001", Species: "Tilapia", WeightKg: 2.25, Date: "2025-08-15"})
+	seedCatch(stub, Catch{CatchID: "C004", FisherID: "F002", Species: "Tilapia", WeightKg: 99, Date: "2025-09-01"})
+
+	// Another fisher can't read it
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F002")
+	_, err := (&SmartContract{}).GetFisherCatchSummary(ctx, "F001")
+	if !isContractError(err, "UNAUTHORIZED", "only the fisher or an authority can view a catch summary") {
+		t.Errorf("GetFisherCatchSummary should fail for another fisher, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "F001")
+	result, err := (&SmartContract{}).GetFisherCatchSummary(ctx, "F001")
+	if err != nil {
+		t.Fatalf("GetFisherCatchSummary failed: %v", err)
+	}
+	var summary FisherCatchSummary
+	json.Unmarshal([]byte(result), &summary)
+	if summary.TotalCatches != 3 || summary.TotalWeightKg != 16.75 {
+		t.Errorf("expected 3 catches totalling 16.75 kg, got %+v", summary)
+	}
+	if tilapia := summary.BySpecies["Tilapia"]; tilapia == nil || tilapia.Count != 2 || tilapia.WeightKg != 12.75 {
+		t.Errorf("unexpected Tilapia totals %+v", tilapia)
+	}
+	if summary.FirstCatchDate != "2025-07-30" || summary.LastCatchDate != "2025-08-15" {
+		t.Errorf("expected dates 2025-07-30..2025-08-15, got %s..%s", summary.FirstCatchDate, summary.LastCatchDate)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", VesselID: "V001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", VesselID: "V001", Species: "Tilapia", WeightKg: 4.0, Date: "2025-08-09"})
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-10"})
+	stub.PutState("BATCH_B001", batchBytes)
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BatchIDs: []string{"B001"}, BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err := (&SmartContract{}).ArchiveCatch(ctx, "C002")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can archive catches") {
+		t.Errorf("ArchiveCatch should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).ArchiveCatch(ctx, "C002"); err != nil {
+		t.Fatalf("ArchiveCatch failed: %v", err)
+	}
+
+	// Still on the ledger
+	catch, err := (&SmartContract{}).GetCatch(ctx, "C002")
+	if err != nil || !catch.Archived {
+		t.Errorf("archived catch should remain readable, got %+v (%v)", catch, err)
+	}
+
+	// Hidden by default, returned on request
+	catches, _ := listFisherCatches(ctx, "F001", false)
+	if len(catches) != 1 || catches[0].CatchID != "C001" {
+		t.Errorf("expected only C001 by default, got %v", catches)
+	}
+	catches, _ = listFisherCatches(ctx, "F001", true)
+	if len(catches) != 2 {
+		t.Errorf("expected 2 catches with includeArchived, got %d", len(catches))
+	}
+
+	var report []Catch
+	result, _ := (&SmartContract{}).GenerateReport(ctx, "2025-08-01", "2025-08-31", false)
+	json.Unmarshal([]byte(result), &report)
+	if len(report) != 1 {
+		t.Errorf("expected 1 catch in default report, got %d", len(report))
+	}
+	result, _ = (&SmartContract{}).GenerateReport(ctx, "2025-08-01", "2025-08-31", true)
+	json.Unmarshal([]byte(result), &report)
+	if len(report) != 2 {
+		t.Errorf("expected 2 catches with includeArchived, got %d", len(report))
+	}
+	for _, includeArchived := range []bool{false, true} {
+		want := map[bool]int{false: 1, true: 2}[includeArchived]
+		paged, _ := (&SmartContract{}).GenerateReportPaged(ctx, "2025-08-01", "2025-08-31", 10, "", includeArchived)
+		if len(paged.Catches) != want {
+			t.Errorf("GenerateReportPaged(includeArchived=%v): expected %d catches, got %d", includeArchived, want, len(paged.Catches))
+		}
+		all, _ := (&SmartContract{}).GetAllCatches(ctx, 10, "", includeArchived)
+		if len(all.Catches) != want {
+			t.Errorf("GetAllCatches(includeArchived=%v): expected %d catches, got %d", includeArchived, want, len(all.Catches))
+		}
+		byVessel, _ := (&SmartContract{}).GetCatchesByVessel(ctx, "V001", includeArchived)
+		if len(byVessel) != want {
+			t.Errorf("GetCatchesByVessel(includeArchived=%v): expected %d catches, got %d", includeArchived, want, len(byVessel))
+		}
+	}
+
+	if err := (&SmartContract{}).ArchiveOrder(ctx, "O001"); err != nil {
+		t.Fatalf("ArchiveOrder failed: %v", err)
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if !order.Archived {
+		t.Error("order O001 should be archived")
+	}
+	for _, includeArchived := range []bool{false, true} {
+		want := map[bool]int{false: 0, true: 1}[includeArchived]
+		byBuyer, _ := (&SmartContract{}).GetOrdersByBuyer(ctx, "BUY1", includeArchived)
+		if len(byBuyer) != want {
+			t.Errorf("GetOrdersByBuyer(includeArchived=%v): expected %d orders, got %d", includeArchived, want, len(byBuyer))
+		}
+		byBatch, _ := (&SmartContract{}).GetOrdersByBatch(ctx, "B001", includeArchived)
+		if len(byBatch) != want {
+			t.Errorf("GetOrdersByBatch(includeArchived=%v): expected %d orders, got %d", includeArchived, want, len(byBatch))
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).ArchiveBatch(ctx, "B001"); err != nil {
+		t.Fatalf("ArchiveBatch failed: %v", err)
+	}
+	batch, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	if !batch.Archived {
+		t.Error("batch B001 should be archived")
+	}
+	batches, _ := (&SmartContract{}).GetAllBatchesByProcessor(ctx, "P001", false)
+	if len(batches) != 0 {
+		t.Errorf("expected archived batch to be hidden by default, got %v", batches)
+	}
+	batches, _ = (&SmartContract{}).GetAllBatchesByProcessor(ctx, "P001", true)
+	if len(batches) != 1 {
+		t.Errorf("expected 1 batch with includeArchived, got %d", len(batches))
+	}
+}
+
+func TestGetOrderStatusHistory(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	stub.MockTransactionStart("tx1")
+	if err := (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-10", "250", "USD", "", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	stub.MockTransactionEnd("tx1")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	for i, status := range []string{"confirmed", "shipped"} {
+		txID := []string{"tx2", "tx3"}[i]
+		stub.MockTransactionStart(txID)
+		if err := (&SmartContract{}).UpdateOrderStatus(ctx, "O001", status); err != nil {
+			t.Fatalf("UpdateOrderStatus to %s failed: %v", status, err)
+		}
+		stub.MockTransactionEnd(txID)
+	}
+
+	result, err := (&SmartContract{}).GetOrderStatusHistory(ctx, "O001")
+	if err != nil {
+		t.Fatalf("GetOrderStatusHistory failed: %v", err)
+	}
+	var history []OrderStatusEntry
+	if err := json.Unmarshal([]byte(result), &history); err != nil {
+		t.Fatalf("GetOrderStatusHistory returned invalid JSON: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 history entries, got %+v", history)
+	}
+	for i, want := range []string{"placed", "confirmed", "shipped"} {
+		if history[i].Status != want {
+			t.Errorf("entry %d: expected status %s, got %s", i, want, history[i].Status)
+		}
+	}
+	if history[0].TxID != "tx1" || history[2].TxID != "tx3" {
+		t.Errorf("unexpected transaction IDs %+v", history)
+	}
+}
+
+func TestBatchBestBefore(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 5, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F001", Species: "Lungfish", WeightKg: 5, Date: "2025-08-01"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetSpeciesShelfLife(ctx, "Tilapia", "10"); err != nil {
+		t.Fatalf("SetSpeciesShelfLife failed: %v", err)
+	}
+	if err := (&SmartContract{}).SetSpeciesShelfLife(ctx, "Nile Perch", "4"); err != nil {
+		t.Fatalf("SetSpeciesShelfLife failed: %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+
+	// Computed from the most perishable species
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001", "C002"}, "P001", "2025-08-02", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	batch, _ := (&SmartContract{}).GetBatch(ctx, "B001")
+	if batch.BestBefore != "2025-08-06" {
+		t.Errorf("expected best before 2025-08-06, got %s", batch.BestBefore)
+	}
+
+	// Unconfigured species fall back to the default shelf life
+	if err := (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C003"}, "P001", "2025-08-02", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	batch, _ = (&SmartContract{}).GetBatch(ctx, "B002")
+	if batch.BestBefore != "2025-08-09" {
+		t.Errorf("expected default best before 2025-08-09, got %s", batch.BestBefore)
+	}
+
+	// Supplied date is kept
+	if err := (&SmartContract{}).CreateBatch(ctx, "B003", []string{"C001"}, "P001", "2025-08-02", "2025-08-20", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	batch, _ = (&SmartContract{}).GetBatch(ctx, "B003")
+	if batch.BestBefore != "2025-08-20" {
+		t.Errorf("expected supplied best before 2025-08-20, got %s", batch.BestBefore)
+	}
+
+	// B001 is delivered, so only B002 has expired by 2025-08-10
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "delivered", Date: "2025-08-03"})
+	stub.PutState("ORDER_O001", orderBytes)
+	expiring, err := (&SmartContract{}).GetExpiringBatches(ctx, "2025-08-10")
+	if err != nil {
+		t.Fatalf("GetExpiringBatches failed: %v", err)
+	}
+	if len(expiring) != 1 || expiring[0].BatchID != "B002" {
+		t.Errorf("expected only B002 expiring, got %v", expiring)
+	}
+
+	expiring, _ = (&SmartContract{}).GetExpiringBatches(ctx, "2025-08-08")
+	if len(expiring) != 0 {
+		t.Errorf("expected nothing expiring by 2025-08-08, got %v", expiring)
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetID("x509::CN=processor1")
+	ctx.GetClientIdentity().SetMSPID("Org2MSP")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+
+	result, err := (&SmartContract{}).WhoAmI(ctx)
+	if err != nil {
+		t.Fatalf("WhoAmI failed: %v", err)
+	}
+	var info CallerInfo
+	if err := json.Unmarshal([]byte(result), &info); err != nil {
+		t.Fatalf("WhoAmI returned invalid JSON: %v", err)
+	}
+	if info != (CallerInfo{ID: "x509::CN=processor1", MSPID: "Org2MSP", Role: "processor"}) {
+		t.Errorf("unexpected caller info %+v", info)
+	}
+}
+
+func TestMSPPolicy(t *testing.T) {
+	_, ctx := setupStub(t)
+	sc := &SmartContract{}
+
+	// No policy: attribute roles apply
+	ctx.GetClientIdentity().SetMSPID("Org1MSP")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if !sc.hasRole(ctx, "authority") || sc.hasRole(ctx, "processor") {
+		t.Error("without an MSP policy the role attribute should decide")
+	}
+
+	if err := sc.SetMSPPolicy(ctx, "processor", "Org2MSP"); err != nil {
+		t.Fatalf("SetMSPPolicy failed: %v", err)
+	}
+
+	// Allowed MSP, even without the processor attribute
+	ctx.GetClientIdentity().SetMSPID("Org2MSP")
+	if !sc.hasRole(ctx, "processor") {
+		t.Error("caller from Org2MSP should pass the processor policy")
+	}
+
+	// Disallowed MSP, even with the processor attribute
+	ctx.GetClientIdentity().SetMSPID("Org1MSP")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if sc.hasRole(ctx, "processor") {
+		t.Error("caller from Org1MSP should fail the processor policy")
+	}
+
+	// Actions without a policy still fall back to attributes
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if !sc.hasRole(ctx, "buyer") {
+		t.Error("buyer has no MSP policy and should use the role attribute")
+	}
+	err := sc.SetMSPPolicy(ctx, "buyer", "Org1MSP")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set MSP policies") {
+		t.Errorf("SetMSPPolicy should fail for non-authority, got %v", err)
+	}
+}
+
+func TestGenerateReportCSV(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: `Perch, "Nile"`, WeightKg: 4, Date: "2025-08-10"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	_, err := (&SmartContract{}).GenerateReportCSV(ctx, "2025-08-01", "2025-08-31")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can generate reports") {
+		t.Errorf("GenerateReportCSV should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	result, err := (&SmartContract{}).GenerateReportCSV(ctx, "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GenerateReportCSV failed: %v", err)
+	}
+	want := "catchId,fisherId,species,weightKg,date\n" +
+		"C001,F001,Tilapia,10.5,2025-08-09\n" +
+		`C002,F001,"Perch, ""Nile""",4,2025-08-10` + "\n"
+	if result != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", result, want)
+	}
+}
+
+func TestGetUnbatchedCatches(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, id := range []string{"C001", "C002", "C003", "C004"} {
+		seedCatch(stub, Catch{CatchID: id, FisherID: "F001", Species: "Tilapia", WeightKg: 5, Date: "2025-08-09"})
+	}
+
+	unbatched, err := (&SmartContract{}).GetUnbatchedCatches(ctx)
+	if err != nil || len(unbatched) != 4 {
+		t.Fatalf("expected all 4 catches unbatched, got %d (%v)", len(unbatched), err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001", "C003"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	unbatched, err = (&SmartContract{}).GetUnbatchedCatches(ctx)
+	if err != nil {
+		t.Fatalf("GetUnbatchedCatches failed: %v", err)
+	}
+	if len(unbatched) != 2 || unbatched[0].CatchID != "C002" || unbatched[1].CatchID != "C004" {
+		t.Errorf("expected C002 and C004 unbatched, got %v", unbatched)
+	}
+}
+
+func TestValidateBatchWeight(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.5, Date: "2025-08-09"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	// Overstated by 0.4 kg; creation does not reject it
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001", "C002"}, "P001", "2025-08-10", "", "15.4", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	check, err := (&SmartContract{}).ValidateBatchWeight(ctx, "B001", "0.5")
+	if err != nil {
+		t.Fatalf("ValidateBatchWeight failed: %v", err)
+	}
+	if !check.WithinTolerance || check.CatchWeightKg != 15 || check.DeclaredWeightKg != 15.4 {
+		t.Errorf("expected 15.4 kg within 0.5 kg of 15 kg, got %+v", check)
+	}
+
+	check, err = (&SmartContract{}).ValidateBatchWeight(ctx, "B001", "0.1")
+	if err != nil {
+		t.Fatalf("ValidateBatchWeight failed: %v", err)
+	}
+	if check.WithinTolerance {
+		t.Errorf("expected 15.4 kg outside 0.1 kg of 15 kg, got %+v", check)
+	}
+
+	// Batches without a declaration can't be checked
+	if err := (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C001"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	_, err = (&SmartContract{}).ValidateBatchWeight(ctx, "B002", "0.5")
+	if err == nil || err.Error() != "batch B002 has no declared weight" {
+		t.Errorf("ValidateBatchWeight should fail without a declared weight, got %v", err)
+	}
+}
+
+func TestPlaceOrderOpenOrderLimit(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	deliveredBytes, _ := json.Marshal(Order{OrderID: "O000", BatchID: "B001", BuyerID: "BUY001", Status: "delivered", Date: "2025-08-01"})
+	stub.PutState("ORDER_O000", deliveredBytes)
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetMaxOpenOrders(ctx, "2"); err != nil {
+		t.Fatalf("SetMaxOpenOrders failed: %v", err)
+	}
+
+	// Under the limit; the delivered order doesn't count
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	for _, orderID := range []string{"O001", "O002"} {
+		if err := (&SmartContract{}).PlaceOrder(ctx, orderID, "B001", "BUY001", "2025-08-09", "250", "USD", "", ""); err != nil {
+			t.Fatalf("PlaceOrder %s failed: %v", orderID, err)
+		}
+	}
+
+	// At the limit
+	err := (&SmartContract{}).PlaceOrder(ctx, "O003", "B001", "BUY001", "2025-08-09", "250", "USD", "", "")
+	if err == nil || err.Error() != "open order limit reached" {
+		t.Errorf("PlaceOrder should fail at the limit, got %v", err)
+	}
+	if orderBytes, _ := stub.GetState("ORDER_O003"); orderBytes != nil {
+		t.Error("order over the limit should not be stored")
+	}
+
+	// Other buyers are unaffected
+	if err := (&SmartContract{}).PlaceOrder(ctx, "O004", "B001", "BUY002", "2025-08-09", "250", "USD", "", ""); err != nil {
+		t.Errorf("PlaceOrder for another buyer failed: %v", err)
+	}
+
+	// Only authorities set the limit
+	err = (&SmartContract{}).SetMaxOpenOrders(ctx, "5")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set the open order limit") {
+		t.Errorf("SetMaxOpenOrders should fail for non-authority, got %v", err)
+	}
+}
+
+func TestAttachCertificate(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	// No certificates yet
+	certs, err := (&SmartContract{}).GetBatchCertificates(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatchCertificates failed: %v", err)
+	}
+	if certs == nil || len(certs) != 0 {
+		t.Errorf("expected empty certificate list, got %v", certs)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).AttachCertificate(ctx, "B001", "health", "HC-2025-001", "Fisheries Dept", "2025-08-10"); err != nil {
+		t.Fatalf("AttachCertificate failed: %v", err)
+	}
+	if err := (&SmartContract{}).AttachCertificate(ctx, "B001", "catch", "CC-2025-042", "Port Authority", "2025-08-11"); err != nil {
+		t.Fatalf("AttachCertificate failed: %v", err)
+	}
+
+	certs, err = (&SmartContract{}).GetBatchCertificates(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatchCertificates failed: %v", err)
+	}
+	expected := []Certificate{
+		{Type: "health", Reference: "HC-2025-001", IssuedBy: "Fisheries Dept", IssuedDate: "2025-08-10"},
+		{Type: "catch", Reference: "CC-2025-042", IssuedBy: "Port Authority", IssuedDate: "2025-08-11"},
+	}
+	if len(certs) != len(expected) {
+		t.Fatalf("expected %d certificates, got %d", len(expected), len(certs))
+	}
+	for i := range expected {
+		if certs[i] != expected[i] {
+			t.Errorf("certificate %d: expected %+v, got %+v", i, expected[i], certs[i])
+		}
+	}
+
+	// Invalid issue date
+	err = (&SmartContract{}).AttachCertificate(ctx, "B001", "health", "HC-2025-002", "Fisheries Dept", "10/08/2025")
+	if err == nil || err.Error() != `invalid date "10/08/2025", expected YYYY-MM-DD` {
+		t.Errorf("AttachCertificate should reject an invalid date, got %v", err)
+	}
+
+	// Only authorities attach certificates
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	err = (&SmartContract{}).AttachCertificate(ctx, "B001", "health", "HC-2025-003", "Fisheries Dept", "2025-08-10")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can attach certificates") {
+		t.Errorf("AttachCertificate should fail for non-authority, got %v", err)
+	}
+}
+
+// encoding/json writes map keys in sorted order, so report maps serialise identically on every peer
+func TestCatchStatsDeterministicJSON(t *testing.T) {
+	stats := map[string]*SpeciesStats{
+		"Tilapia":    {Count: 2, WeightKg: 15},
+		"Nile Perch": {Count: 1, WeightKg: 8.5},
+		"Catfish":    {Count: 3, WeightKg: 4.25},
+	}
+
+	first, err := json.Marshal(CatchStats{TotalCatches: 6, BySpecies: stats})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	second, err := json.Marshal(CatchStats{TotalCatches: 6, BySpecies: stats})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("serializations differ:\n%s\n%s", first, second)
+	}
+
+	expected := `{"Catfish":{"count":3,"weightKg":4.25},"Nile Perch":{"count":1,"weightKg":8.5},"Tilapia":{"count":2,"weightKg":15}}`
+	if !strings.Contains(string(first), `"bySpecies":`+expected) {
+		t.Errorf("expected species sorted by name, got %s", first)
+	}
+
+	// Round-trips into the same shape
+	var decoded CatchStats
+	if err := json.Unmarshal(first, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if decoded.BySpecies["Catfish"] == nil || decoded.BySpecies["Catfish"].Count != 3 {
+		t.Errorf("expected Catfish stats after round-trip, got %+v", decoded.BySpecies)
+	}
+}
+
+func TestGetCatchesBySpeciesAndDateRange(t *testing.T) {
+	// The mock stub has no rich query support, so this exercises the range scan fallback
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 12, Date: "2025-08-10"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F002", Species: "Nile Perch", WeightKg: 20, Date: "2025-08-10"})
+	seedCatch(stub, Catch{CatchID: "C004", FisherID: "F002", Species: "Tilapia", WeightKg: 8, Date: "2025-09-01"})
+	seedCatch(stub, Catch{CatchID: "C005", FisherID: "F002", Species: "Tilapia", WeightKg: 5, Date: "2025-08-12", Archived: true})
+
+	catches, err := (&SmartContract{}).GetCatchesBySpeciesAndDateRange(ctx, "Tilapia", "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GetCatchesBySpeciesAndDateRange failed: %v", err)
+	}
+	if len(catches) != 2 || catches[0].CatchID != "C001" || catches[1].CatchID != "C002" {
+		t.Errorf("expected catches C001 and C002, got %+v", catches)
+	}
+
+	// No matches
+	catches, err = (&SmartContract{}).GetCatchesBySpeciesAndDateRange(ctx, "Nile Perch", "2025-09-01", "2025-09-30")
+	if err != nil {
+		t.Fatalf("GetCatchesBySpeciesAndDateRange failed: %v", err)
+	}
+	if catches == nil || len(catches) != 0 {
+		t.Errorf("expected empty slice, got %v", catches)
+	}
+
+	// Invalid date
+	_, err = (&SmartContract{}).GetCatchesBySpeciesAndDateRange(ctx, "Tilapia", "2025-08-01", "August")
+	if err == nil || err.Error() != `invalid date "August", expected YYYY-MM-DD` {
+		t.Errorf("GetCatchesBySpeciesAndDateRange should reject an invalid date, got %v", err)
+	}
+}
+
+func TestIdempotentRequests(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+
+	// A retried catch succeeds without a duplicate error or a second write
+	for i := 0; i < 2; i++ {
+		err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "REQ-CATCH-1")
+		if err != nil {
+			t.Fatalf("LogCatch attempt %d failed: %v", i+1, err)
+		}
+	}
+	catches, err := listFisherCatches(ctx, "F001", false)
+	if err != nil {
+		t.Fatalf("listFisherCatches failed: %v", err)
+	}
+	if len(catches) != 1 {
+		t.Errorf("expected 1 catch after a retried request, got %d", len(catches))
+	}
+	requestKey, _ := stub.CreateCompositeKey("req~fn~id", []string{"LogCatch", "REQ-CATCH-1"})
+	if seen, _ := stub.GetState(requestKey); seen == nil {
+		t.Error("request ID should be recorded")
+	}
+
+	// Reusing a request ID for a different batch is an error, not a retry
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	for i := 0; i < 2; i++ {
+		if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001"}, "P001", "2025-08-10", "", "", "REQ-BATCH-1"); err != nil {
+			t.Fatalf("CreateBatch attempt %d failed: %v", i+1, err)
+		}
+	}
+	err = (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C001"}, "P001", "2025-08-10", "", "", "REQ-BATCH-1")
+	if err == nil || err.Error() != "request REQ-BATCH-1 was already used by CreateBatch for B001, not B002" {
+		t.Errorf("CreateBatch should reject a request ID reused for another batch, got %v", err)
+	}
+	if batchBytes, _ := stub.GetState("BATCH_B002"); batchBytes != nil {
+		t.Error("CreateBatch should not store a second batch under a reused request ID")
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	for i := 0; i < 2; i++ {
+		if err := (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-11", "250", "USD", "", "REQ-ORDER-1"); err != nil {
+			t.Fatalf("PlaceOrder attempt %d failed: %v", i+1, err)
+		}
+	}
+	orders, err := (&SmartContract{}).GetOrdersByBuyer(ctx, "BUY001", false)
+	if err != nil {
+		t.Fatalf("GetOrdersByBuyer failed: %v", err)
+	}
+	if len(orders) != 1 {
+		t.Errorf("expected 1 order after a retried request, got %d", len(orders))
+	}
+
+	// Request IDs are scoped to the function, so one used by LogCatch still places an order
+	if err := (&SmartContract{}).PlaceOrder(ctx, "O002", "B001", "BUY001", "2025-08-11", "250", "USD", "", "REQ-CATCH-1"); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	if orderBytes, _ := stub.GetState("ORDER_O002"); orderBytes == nil {
+		t.Error("PlaceOrder should store an order under a request ID first used by LogCatch")
+	}
+
+	// Without a request ID, a repeat is still rejected as a duplicate
+	err = (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "catch C001 already exists" {
+		t.Errorf("LogCatch without a request ID should reject a duplicate, got %v", err)
+	}
+}
+
+func TestGetFisherByGovtID(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RegisterFisher(ctx, "F001", "John Doe", "GOV123"); err != nil {
+		t.Fatalf("RegisterFisher failed: %v", err)
+	}
+	if err := (&SmartContract{}).RegisterFisher(ctx, "F002", "Jane Roe", "GOV456"); err != nil {
+		t.Fatalf("RegisterFisher failed: %v", err)
+	}
+
+	// Hit
+	fisher, err := (&SmartContract{}).GetFisherByGovtID(ctx, "GOV456")
+	if err != nil {
+		t.Fatalf("GetFisherByGovtID failed: %v", err)
+	}
+	if fisher.ID != "F002" || fisher.GovtID != "GOV456" {
+		t.Errorf("expected fisher F002 with GovtID GOV456, got %+v", fisher)
+	}
+
+	// Miss
+	_, err = (&SmartContract{}).GetFisherByGovtID(ctx, "GOV999")
+	if !isContractError(err, "NOT_FOUND", "no fisher with govt ID GOV999") {
+		t.Errorf("GetFisherByGovtID should fail for an unknown govt ID, got %v", err)
+	}
+
+	// Restricted to authority
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	_, err = (&SmartContract{}).GetFisherByGovtID(ctx, "GOV123")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can look up fishers by govt ID") {
+		t.Errorf("GetFisherByGovtID should fail for non-authority, got %v", err)
+	}
+}
+
+func TestCondemnCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 4.5, Date: "2025-08-09"})
+
+	// Only authorities condemn
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	err := (&SmartContract{}).CondemnCatch(ctx, "C001", "spoiled on landing")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can condemn catches") {
+		t.Errorf("CondemnCatch should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).CondemnCatch(ctx, "C001", "spoiled on landing"); err != nil {
+		t.Fatalf("CondemnCatch failed: %v", err)
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C001")
+	if !catch.Condemned || catch.CondemnReason != "spoiled on landing" {
+		t.Errorf("expected C001 condemned for spoilage, got %+v", catch)
+	}
+
+	// A condemned catch can't be batched, even alongside good ones
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	err = (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C002", "C001"}, "P001", "2025-08-10", "", "", "")
+	if err == nil || err.Error() != "catch C001 is condemned and cannot be batched" {
+		t.Errorf("CreateBatch should reject a condemned catch, got %v", err)
+	}
+	if batchBytes, _ := stub.GetState("BATCH_B001"); batchBytes != nil {
+		t.Error("batch with a condemned catch should not be stored")
+	}
+
+	// Sound catches still batch normally
+	if err := (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C002"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Errorf("CreateBatch failed for a sound catch: %v", err)
+	}
+}
+
+func TestFisherPIICollection(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RegisterFisher(ctx, "F001", "John Doe", "GOV123"); err != nil {
+		t.Fatalf("RegisterFisher failed: %v", err)
+	}
+
+	// GovtID is kept out of the broader collection
+	var basic Fisher
+	fisherBytes, _ := stub.GetPrivateData("FisherCollection", "FISHER_F001")
+	if err := json.Unmarshal(fisherBytes, &basic); err != nil {
+		t.Fatalf("failed to unmarshal fisher: %v", err)
+	}
+	if basic.Name != "John Doe" || basic.GovtID != "" {
+		t.Errorf("expected FisherCollection to hold the name without GovtID, got %+v", basic)
+	}
+	var pii FisherPII
+	piiBytes, _ := stub.GetPrivateData("FisherPIICollection", "FISHER_F001")
+	if err := json.Unmarshal(piiBytes, &pii); err != nil {
+		t.Fatalf("failed to unmarshal fisher PII: %v", err)
+	}
+	if pii.GovtID != "GOV123" {
+		t.Errorf("expected FisherPIICollection to hold GOV123, got %+v", pii)
+	}
+
+	// Authority sees both
+	fisher, err := (&SmartContract{}).GetFisher(ctx, "F001")
+	if err != nil || fisher.Name != "John Doe" || fisher.GovtID != "GOV123" {
+		t.Errorf("authority should see name and GovtID, got %+v (%v)", fisher, err)
+	}
+
+	// Limited caller sees the name only
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY001")
+	fisher, err = (&SmartContract{}).GetFisher(ctx, "F001")
+	if err != nil || fisher.Name != "John Doe" || fisher.GovtID != "" {
+		t.Errorf("buyer should see name without GovtID, got %+v (%v)", fisher, err)
+	}
+}
+
+func TestVerifyCatchHash(t *testing.T) {
+	_, ctx := setupStub(t)
+	catchJSON := `{"catchId":"C001","fisherId":"F001","species":"Tilapia","weightKg":10.5,"date":"2025-08-09"}`
+	if err := (&SmartContract{}).PutCatchPrivate(ctx, "FisherCollection", catchJSON); err != nil {
+		t.Fatalf("PutCatchPrivate failed: %v", err)
+	}
+
+	// The buyer hashes the copy they received off-chain
+	sum := sha256.Sum256([]byte(catchJSON))
+	match, err := (&SmartContract{}).VerifyCatchHash(ctx, "FisherCollection", "C001", hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("VerifyCatchHash failed: %v", err)
+	}
+	if !match {
+		t.Error("expected the hash of the stored catch to match")
+	}
+
+	// A tampered copy doesn't match
+	tampered := sha256.Sum256([]byte(strings.Replace(catchJSON, "10.5", "105", 1)))
+	match, err = (&SmartContract{}).VerifyCatchHash(ctx, "FisherCollection", "C001", hex.EncodeToString(tampered[:]))
+	if err != nil {
+		t.Fatalf("VerifyCatchHash failed: %v", err)
+	}
+	if match {
+		t.Error("expected the hash of a tampered catch not to match")
+	}
+
+	// Unknown catch
+	_, err = (&SmartContract{}).VerifyCatchHash(ctx, "FisherCollection", "C999", hex.EncodeToString(sum[:]))
+	if !isContractError(err, "NOT_FOUND", "private catch C999 not found in FisherCollection") {
+		t.Errorf("VerifyCatchHash should fail for an unknown catch, got %v", err)
+	}
+
+	// Malformed hash
+	_, err = (&SmartContract{}).VerifyCatchHash(ctx, "FisherCollection", "C001", "not-hex")
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid hash "not-hex"`) {
+		t.Errorf("VerifyCatchHash should reject a malformed hash, got %v", err)
+	}
+}
+
+func TestDailyCatchLimit(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetDailyCatchLimit(ctx, "20"); err != nil {
+		t.Fatalf("SetDailyCatchLimit failed: %v", err)
+	}
+
+	// Under the limit
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "12", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch under the limit failed: %v", err)
+	}
+
+	// Exactly at the limit
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "8", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Fatalf("LogCatch at the limit failed: %v", err)
+	}
+
+	// Over the limit on the same day
+	err := (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Tilapia", "0.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "daily catch limit of 20 kg exceeded for fisher F001 on 2025-08-09" {
+		t.Errorf("LogCatch over the limit should fail, got %v", err)
+	}
+
+	// A new day starts from zero
+	if err := (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Tilapia", "15", "2025-08-10", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch on a new day failed: %v", err)
+	}
+
+	// Only authorities set the limit
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).SetDailyCatchLimit(ctx, "50")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set the daily catch limit") {
+		t.Errorf("SetDailyCatchLimit should fail for non-authority, got %v", err)
+	}
+}
+
+func TestBatchProcessingTime(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	received := time.Date(2025, 8, 10, 8, 0, 0, 0, time.UTC)
+
+	// Received
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	stub.TxTimestamp = timestamppb.New(received)
+	if err := (&SmartContract{}).CreateBatch(ctx, "B001", []string{"C001"}, "P001", "2025-08-10", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	timing, err := (&SmartContract{}).GetBatchProcessingTime(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatchProcessingTime failed: %v", err)
+	}
+	if timing.ReceivedToProcessedSeconds != 0 || timing.ReceivedToShippedSeconds != 0 {
+		t.Errorf("expected no durations before processing, got %+v", timing)
+	}
+
+	// Processed four hours later
+	stub.TxTimestamp = timestamppb.New(received.Add(4 * time.Hour))
+	if err := (&SmartContract{}).MarkBatchProcessed(ctx, "B001"); err != nil {
+		t.Fatalf("MarkBatchProcessed failed: %v", err)
+	}
+	err = (&SmartContract{}).MarkBatchProcessed(ctx, "B001")
+	if err == nil || err.Error() != "batch B001 already processed" {
+		t.Errorf("MarkBatchProcessed should fail the second time, got %v", err)
+	}
+
+	// Shipped a day after processing
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "confirmed", Date: "2025-08-10"})
+	stub.PutState("ORDER_O001", orderBytes)
+	stub.TxTimestamp = timestamppb.New(received.Add(28 * time.Hour))
+	if err := (&SmartContract{}).CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-11"); err != nil {
+		t.Fatalf("CreateShipment failed: %v", err)
+	}
+
+	timing, err = (&SmartContract{}).GetBatchProcessingTime(ctx, "B001")
+	if err != nil {
+		t.Fatalf("GetBatchProcessingTime failed: %v", err)
+	}
+	if timing.ReceivedToProcessedSeconds != 4*3600 || timing.ProcessedToShippedSeconds != 24*3600 || timing.ReceivedToShippedSeconds != 28*3600 {
+		t.Errorf("unexpected durations %+v", timing)
+	}
+	if timing.ReceivedToProcessedSeconds <= 0 || timing.ProcessedToShippedSeconds <= 0 || timing.ReceivedToShippedSeconds < timing.ProcessedToShippedSeconds {
+		t.Errorf("expected positive, ordered durations, got %+v", timing)
+	}
+}
+
+func TestInputLengthLimits(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-09"})
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	longID := strings.Repeat("x", 65)
+	longName := strings.Repeat("n", 129)
+	longSpecies := strings.Repeat("s", 65)
+
+	tests := []struct {
+		name  string
+		role  string
+		call  func() error
+		field string
+		max   int
+	}{
+		{"fisher id", "authority", func() error { return (&SmartContract{}).RegisterFisher(ctx, longID, "John Doe", "GOV123") }, "id", 64},
+		{"fisher name", "authority", func() error { return (&SmartContract{}).RegisterFisher(ctx, "F002", longName, "GOV123") }, "name", 128},
+		{"fisher govt id", "authority", func() error { return (&SmartContract{}).RegisterFisher(ctx, "F002", "John Doe", longID) }, "govtId", 64},
+		{"catch id", "fisher", func() error {
+			return (&SmartContract{}).LogCatch(ctx, longID, "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+		}, "catchId", 64},
+		{"catch species", "fisher", func() error {
+			return (&SmartContract{}).LogCatch(ctx, "C002", "F001", longSpecies, "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+		}, "species", 64},
+		{"catch vessel", "fisher", func() error {
+			return (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", longID, "A", "2.5", "", "")
+		}, "vesselId", 64},
+		{"catch request id", "fisher", func() error {
+			return (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", longID)
+		}, "clientRequestId", 64},
+		{"batch id", "processor", func() error {
+			return (&SmartContract{}).CreateBatch(ctx, longID, []string{"C001"}, "P001", "2025-08-10", "", "", "")
+		}, "batchId", 64},
+		{"batch processor", "processor", func() error {
+			return (&SmartContract{}).CreateBatch(ctx, "B002", []string{"C001"}, longID, "2025-08-10", "", "", "")
+		}, "processorId", 64},
+		{"order id", "buyer", func() error {
+			return (&SmartContract{}).PlaceOrder(ctx, longID, "B001", "BUY001", "2025-08-10", "250", "USD", "", "")
+		}, "orderId", 64},
+		{"order buyer", "buyer", func() error {
+			return (&SmartContract{}).PlaceOrder(ctx, "O001", "B001", longID, "2025-08-10", "250", "USD", "", "")
+		}, "buyerId", 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx.GetClientIdentity().SetAttributeValue("hf.Role", tt.role)
+			err := tt.call()
+			expected := fmt.Sprintf("field %s exceeds max length %d", tt.field, tt.max)
+			if err == nil || err.Error() != expected {
+				t.Errorf("expected %q, got %v", expected, err)
+			}
+		})
+	}
+
+	// Values at the limit are accepted
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RegisterFisher(ctx, strings.Repeat("x", 64), strings.Repeat("n", 128), "GOV123"); err != nil {
+		t.Errorf("RegisterFisher at the length limits failed: %v", err)
+	}
+}
+
+func TestGetCatchCountBySpecies(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+
+	// Each call runs in its own transaction so its count delta gets its own key
+	txCount := 0
+	inTx := func(call func() error) error {
+		txCount++
+		txID := fmt.Sprintf("tx%d", txCount)
+		stub.MockTransactionStart(txID)
+		defer stub.MockTransactionEnd(txID)
+		return call()
+	}
+
+	for _, c := range []struct{ id, species string }{{"C001", "Tilapia"}, {"C002", "Tilapia"}, {"C003", "Nile Perch"}} {
+		if err := inTx(func() error {
+			return (&SmartContract{}).LogCatch(ctx, c.id, "F001", c.species, "5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+		}); err != nil {
+			t.Fatalf("LogCatch %s failed: %v", c.id, err)
+		}
+	}
+	batch := `[{"catchId":"C004","fisherId":"F001","species":"Tilapia","weightKg":3,"date":"2025-08-10","grade":"A","storageTempC":2},
+		{"catchId":"C005","fisherId":"F001","species":"Tilapia","weightKg":4,"date":"2025-08-10","grade":"A","storageTempC":2}]`
+	if err := inTx(func() error { return (&SmartContract{}).LogCatchBatch(ctx, batch) }); err != nil {
+		t.Fatalf("LogCatchBatch failed: %v", err)
+	}
+
+	// Counter matches a manual scan
+	count, err := (&SmartContract{}).GetCatchCountBySpecies(ctx, "Tilapia")
+	if err != nil {
+		t.Fatalf("GetCatchCountBySpecies failed: %v", err)
+	}
+	scanned, _ := (&SmartContract{}).GetCatchesBySpeciesAndDateRange(ctx, "Tilapia", "2025-01-01", "2025-12-31")
+	if count != 4 || count != len(scanned) {
+		t.Errorf("expected 4 Tilapia catches matching a scan of %d, got %d", len(scanned), count)
+	}
+	if count, _ := (&SmartContract{}).GetCatchCountBySpecies(ctx, "Nile Perch"); count != 1 {
+		t.Errorf("expected 1 Nile Perch catch, got %d", count)
+	}
+	if count, _ := (&SmartContract{}).GetCatchCountBySpecies(ctx, "Catfish"); count != 0 {
+		t.Errorf("expected 0 Catfish catches, got %d", count)
+	}
+
+	// Archiving and deleting take catches off the count, but only once
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := inTx(func() error { return (&SmartContract{}).ArchiveCatch(ctx, "C001") }); err != nil {
+		t.Fatalf("ArchiveCatch failed: %v", err)
+	}
+	if err := inTx(func() error { return (&SmartContract{}).ArchiveCatch(ctx, "C001") }); err != nil {
+		t.Fatalf("ArchiveCatch failed: %v", err)
+	}
+	if err := inTx(func() error { return (&SmartContract{}).DeleteCatch(ctx, "C001") }); err != nil {
+		t.Fatalf("DeleteCatch failed: %v", err)
+	}
+	if err := inTx(func() error { return (&SmartContract{}).DeleteCatch(ctx, "C002") }); err != nil {
+		t.Fatalf("DeleteCatch failed: %v", err)
+	}
+	count, _ = (&SmartContract{}).GetCatchCountBySpecies(ctx, "Tilapia")
+	scanned, _ = (&SmartContract{}).GetCatchesBySpeciesAndDateRange(ctx, "Tilapia", "2025-01-01", "2025-12-31")
+	if count != 2 || count != len(scanned) {
+		t.Errorf("expected 2 Tilapia catches matching a scan of %d, got %d", len(scanned), count)
+	}
+}
+
+func TestMinLandingWeight(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia", "Nile Perch")
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+
+	// Unconfigured species accept any positive weight
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "Tilapia", "0.1", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch without a minimum failed: %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).SetMinWeight(ctx, "Nile Perch", "2.5"); err != nil {
+		t.Fatalf("SetMinWeight failed: %v", err)
+	}
+
+	// Above and exactly at the minimum
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "Nile Perch", "3", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch above the minimum failed: %v", err)
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C003", "F001", "Nile Perch", "2.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch at the minimum failed: %v", err)
+	}
+
+	// Below the minimum
+	err := (&SmartContract{}).LogCatch(ctx, "C004", "F001", "Nile Perch", "2.499", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != "catch below minimum landing weight for Nile Perch" {
+		t.Errorf("LogCatch below the minimum should fail, got %v", err)
+	}
+
+	// Only authorities set minimums
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).SetMinWeight(ctx, "Tilapia", "1")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can set minimum landing weights") {
+		t.Errorf("SetMinWeight should fail for non-authority, got %v", err)
+	}
+}
+
+func TestAmendOrder(t *testing.T) {
+	stub, ctx := setupStub(t)
+	for _, batchID := range []string{"B001", "B002"} {
+		batchBytes, _ := json.Marshal(Batch{BatchID: batchID, CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+		stub.PutState("BATCH_"+batchID, batchBytes)
+	}
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B001", BuyerID: "BUY1", Status: "confirmed", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+
+	// Another buyer can't amend
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY2")
+	err := (&SmartContract{}).AmendOrder(ctx, "O001", "B002")
+	if !isContractError(err, "UNAUTHORIZED", "only the buyer can amend an order") {
+		t.Errorf("AmendOrder should fail for another buyer, got %v", err)
+	}
+
+	// Valid amendment
+	ctx.GetClientIdentity().SetAttributeValue("hf.EnrollmentID", "BUY1")
+	if err := (&SmartContract{}).AmendOrder(ctx, "O001", "B002"); err != nil {
+		t.Fatalf("AmendOrder failed: %v", err)
+	}
+	order, _ := (&SmartContract{}).GetOrder(ctx, "O001")
+	if order.BatchID != "B002" || order.Status != "placed" {
+		t.Errorf("expected placed order on B002, got %+v", order)
+	}
+
+	// Non-existent batch
+	err = (&SmartContract{}).AmendOrder(ctx, "O001", "B999")
+	if err == nil || err.Error() != "cannot amend order: batch B999 not found" {
+		t.Errorf("AmendOrder should reject a missing batch, got %v", err)
+	}
+
+	// Confirmed orders are fixed
+	err = (&SmartContract{}).AmendOrder(ctx, "O002", "B002")
+	if err == nil || err.Error() != "cannot amend order O002 with status confirmed" {
+		t.Errorf("AmendOrder should reject a confirmed order, got %v", err)
+	}
+	order, _ = (&SmartContract{}).GetOrder(ctx, "O002")
+	if order.BatchID != "B001" {
+		t.Errorf("confirmed order should stay on B001, got %s", order.BatchID)
+	}
+}
+
+func TestSpeciesRegistry(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedFisher(stub, "F001")
+	seedLicense(stub, "F001")
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := (&SmartContract{}).RegisterSpecies(ctx, "TLP", "Nile tilapia", "Oreochromis niloticus"); err != nil {
+		t.Fatalf("RegisterSpecies failed: %v", err)
+	}
+	species, err := (&SmartContract{}).GetSpecies(ctx, "TLP")
+	if err != nil || species.CommonName != "Nile tilapia" || species.ScientificName != "Oreochromis niloticus" {
+		t.Errorf("unexpected species %+v (%v)", species, err)
+	}
+	err = (&SmartContract{}).RegisterSpecies(ctx, "TLP", "Tilapia", "")
+	if err == nil || err.Error() != "species TLP already exists" {
+		t.Errorf("RegisterSpecies should reject a duplicate code, got %v", err)
+	}
+
+	// Registered code
+	if err := (&SmartContract{}).LogCatch(ctx, "C001", "F001", "TLP", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch with a registered code failed: %v", err)
+	}
+
+	// Unregistered code, including a different spelling
+	err = (&SmartContract{}).LogCatch(ctx, "C002", "F001", "tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != `unknown species code "tilapia"` {
+		t.Errorf("LogCatch should reject an unregistered code, got %v", err)
+	}
+
+	// Legacy free text is accepted only while the flag is on
+	if err := (&SmartContract{}).SetLegacySpeciesAllowed(ctx, true); err != nil {
+		t.Fatalf("SetLegacySpeciesAllowed failed: %v", err)
+	}
+	if err := (&SmartContract{}).LogCatch(ctx, "C002", "F001", "tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+		t.Errorf("LogCatch with legacy species failed: %v", err)
+	}
+	if err := (&SmartContract{}).SetLegacySpeciesAllowed(ctx, false); err != nil {
+		t.Fatalf("SetLegacySpeciesAllowed failed: %v", err)
+	}
+	err = (&SmartContract{}).LogCatch(ctx, "C003", "F001", "tilapia", "10.5", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", "")
+	if err == nil || err.Error() != `unknown species code "tilapia"` {
+		t.Errorf("LogCatch should reject legacy species once the flag is off, got %v", err)
+	}
+
+	// Only authorities manage the registry
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	err = (&SmartContract{}).RegisterSpecies(ctx, "NPE", "Nile perch", "Lates niloticus")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can register species") {
+		t.Errorf("RegisterSpecies should fail for non-authority, got %v", err)
+	}
+}
+
+func TestGetCatchesByMSP(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedLicense(stub, "F002")
+
+	// Each org's authority registers its own fisher
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	ctx.GetClientIdentity().SetMSPID("Org1MSP")
+	if err := (&SmartContract{}).RegisterFisher(ctx, "F001", "John Doe", "GOV123"); err != nil {
+		t.Fatalf("RegisterFisher failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetMSPID("Org2MSP")
+	if err := (&SmartContract{}).RegisterFisher(ctx, "F002", "Jane Roe", "GOV456"); err != nil {
+		t.Fatalf("RegisterFisher failed: %v", err)
+	}
+
+	for _, c := range []struct{ catchID, fisherID string }{{"C001", "F001"}, {"C002", "F002"}, {"C003", "F001"}} {
+		if err := (&SmartContract{}).LogCatch(ctx, c.catchID, c.fisherID, "Tilapia", "10", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+			t.Fatalf("LogCatch %s failed: %v", c.catchID, err)
+		}
+	}
+	catch, _ := (&SmartContract{}).GetCatch(ctx, "C002")
+	if catch.MSPID != "Org2MSP" {
+		t.Errorf("expected catch C002 to inherit Org2MSP, got %q", catch.MSPID)
+	}
+
+	// Members see their own org's catches
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	ctx.GetClientIdentity().SetMSPID("Org1MSP")
+	catches, err := (&SmartContract{}).GetCatchesByMSP(ctx, "Org1MSP")
+	if err != nil {
+		t.Fatalf("GetCatchesByMSP failed: %v", err)
+	}
+	if len(catches) != 2 || catches[0].CatchID != "C001" || catches[1].CatchID != "C003" {
+		t.Errorf("expected Org1MSP catches C001 and C003, got %+v", catches)
+	}
+
+	// ...but not another org's
+	_, err = (&SmartContract{}).GetCatchesByMSP(ctx, "Org2MSP")
+	if !isContractError(err, "UNAUTHORIZED", "only members of Org2MSP or an authority can view its catches") {
+		t.Errorf("GetCatchesByMSP should fail for another org, got %v", err)
+	}
+
+	// Authorities can view any org
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	catches, err = (&SmartContract{}).GetCatchesByMSP(ctx, "Org2MSP")
+	if err != nil {
+		t.Fatalf("GetCatchesByMSP failed: %v", err)
+	}
+	if len(catches) != 1 || catches[0].CatchID != "C002" {
+		t.Errorf("expected Org2MSP catch C002, got %+v", catches)
+	}
+}
+
+func TestSpeciesCountDeltas(t *testing.T) {
+	stub, ctx := setupStub(t)
+
+	// 100 concurrent-style increments and 30 decrements, each from its own transaction
+	for i := 0; i < 100; i++ {
+		key, _ := stub.CreateCompositeKey("species~delta", []string{"Tilapia", fmt.Sprintf("tx-add-%d", i)})
+		stub.PutState(key, []byte("1"))
+	}
+	for i := 0; i < 30; i++ {
+		key, _ := stub.CreateCompositeKey("species~delta", []string{"Tilapia", fmt.Sprintf("tx-del-%d", i)})
+		stub.PutState(key, []byte("-1"))
+	}
+	// A batch transaction records its total as one delta
+	key, _ := stub.CreateCompositeKey("species~delta", []string{"Tilapia", "tx-batch"})
+	stub.PutState(key, []byte("5"))
+	// Other species don't leak into the sum
+	key, _ = stub.CreateCompositeKey("species~delta", []string{"Tilapia Hybrid", "tx-other"})
+	stub.PutState(key, []byte("7"))
+
+	count, err := (&SmartContract{}).GetCatchCountBySpecies(ctx, "Tilapia")
+	if err != nil {
+		t.Fatalf("GetCatchCountBySpecies failed: %v", err)
+	}
+	if count != 75 {
+		t.Errorf("expected summed count 75, got %d", count)
+	}
+
+	// A write adds its own delta without touching the others
+	stub.MockTransactionStart("tx-new")
+	if err := adjustSpeciesCount(ctx, "Tilapia", 1); err != nil {
+		t.Fatalf("adjustSpeciesCount failed: %v", err)
+	}
+	stub.MockTransactionEnd("tx-new")
+	if count, _ := (&SmartContract{}).GetCatchCountBySpecies(ctx, "Tilapia"); count != 76 {
+		t.Errorf("expected summed count 76, got %d", count)
+	}
+}
+
+func TestPlaceOrderAmountCurrency(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-03"})
+	stub.PutState("BATCH_B001", batchBytes)
+
+	sc := &SmartContract{}
+	if err := sc.PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-09", "125.50", "EUR", "", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	order, err := sc.GetOrder(ctx, "O001")
+	if err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	if order.Amount != 125.5 || order.Currency != "EUR" {
+		t.Errorf("expected 125.5 EUR, got %g %s", order.Amount, order.Currency)
+	}
+
+	tests := []struct {
+		amount, currency, want string
+	}{
+		{"0", "USD", "amount must be positive"},
+		{"-5", "USD", "amount must be positive"},
+		{"abc", "USD", "invalid amount value 'abc'"},
+		{"10", "usd", `invalid currency code "usd", expected three uppercase letters`},
+		{"10", "US", `invalid currency code "US", expected three uppercase letters`},
+	}
+	for i, tt := range tests {
+		err := sc.PlaceOrder(ctx, fmt.Sprintf("O1%02d", i), "B001", "BUY001", "2025-08-09", tt.amount, tt.currency, "", "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("PlaceOrder(%s, %s): expected %q, got %v", tt.amount, tt.currency, tt.want, err)
+		}
+	}
+}
+
+func TestBatchLotNumbers(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-09"})
+
+	sc := &SmartContract{}
+	for _, b := range []struct{ batchId, processorId, date, want string }{
+		{"B001", "P001", "2025-08-09", "P001-20250809-001"},
+		{"B002", "P001", "2025-08-09", "P001-20250809-002"},
+		{"B003", "P001", "2025-08-10", "P001-20250810-001"},
+		{"B004", "P002", "2025-08-09", "P002-20250809-001"},
+	} {
+		if err := sc.CreateBatch(ctx, b.batchId, []string{"C001"}, b.processorId, b.date, "", "", ""); err != nil {
+			t.Fatalf("CreateBatch %s failed: %v", b.batchId, err)
+		}
+		batch, err := sc.GetBatch(ctx, b.batchId)
+		if err != nil {
+			t.Fatalf("GetBatch %s failed: %v", b.batchId, err)
+		}
+		if batch.LotNumber != b.want {
+			t.Errorf("batch %s: expected lot %s, got %s", b.batchId, b.want, batch.LotNumber)
+		}
+	}
+
+	batch, err := sc.GetBatchByLotNumber(ctx, "P001-20250809-002")
+	if err != nil || batch.BatchID != "B002" {
+		t.Errorf("expected batch B002 for lot P001-20250809-002, got %v, %v", batch, err)
+	}
+
+	// Lot numbers of deleted batches are not reissued
+	if err := sc.DeleteBatch(ctx, "B002"); err != nil {
+		t.Fatalf("DeleteBatch failed: %v", err)
+	}
+	if _, err := sc.GetBatchByLotNumber(ctx, "P001-20250809-002"); !isContractError(err, "NOT_FOUND", "no batch with lot number P001-20250809-002") {
+		t.Errorf("expected lot lookup to fail after delete, got %v", err)
+	}
+	if err := sc.CreateBatch(ctx, "B005", []string{"C001"}, "P001", "2025-08-09", "", "", ""); err != nil {
+		t.Fatalf("CreateBatch B005 failed: %v", err)
+	}
+	if batch, _ := sc.GetBatch(ctx, "B005"); batch.LotNumber != "P001-20250809-003" {
+		t.Errorf("expected lot P001-20250809-003, got %s", batch.LotNumber)
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+
+	sc := &SmartContract{}
+	if err := sc.SetSpeciesQuota(ctx, "Tilapia", "5000"); err != nil {
+		t.Fatalf("SetSpeciesQuota failed: %v", err)
+	}
+	if err := sc.SetMinWeight(ctx, "Tilapia", "0.5"); err != nil {
+		t.Fatalf("SetMinWeight failed: %v", err)
+	}
+	if err := sc.SetQRBaseURL(ctx, "https://trace.example.com/batch/"); err != nil {
+		t.Fatalf("SetQRBaseURL failed: %v", err)
+	}
+	if err := sc.SetMaxOpenOrders(ctx, "3"); err != nil {
+		t.Fatalf("SetMaxOpenOrders failed: %v", err)
+	}
+	if err := sc.SetRegionSpecies(ctx, "Lake Victoria", []string{"Tilapia"}); err != nil {
+		t.Fatalf("SetRegionSpecies failed: %v", err)
+	}
+
+	result, err := sc.GetConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetConfig failed: %v", err)
+	}
+	var config map[string]string
+	if err := json.Unmarshal([]byte(result), &config); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	expected := map[string]string{
+		"CFG_QUOTA_Tilapia":                "5000",
+		"CFG_MIN_WEIGHT_Tilapia":           "0.5",
+		"CFG_QR_BASE_URL":                  "https://trace.example.com/batch",
+		"CFG_MAX_OPEN_ORDERS":              "3",
+		"CFG_REGION_SPECIES_Lake Victoria": `["Tilapia"]`,
+	}
+	if len(config) != len(expected) {
+		t.Errorf("expected %d config keys, got %v", len(expected), config)
+	}
+	for key, value := range expected {
+		if config[key] != value {
+			t.Errorf("expected %s = %s, got %q", key, value, config[key])
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if _, err := sc.GetConfig(ctx); !isContractError(err, "UNAUTHORIZED", "only authority can view configuration") {
+		t.Errorf("GetConfig should fail for non-authority, got %v", err)
+	}
+}
+
+func TestReassignCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	seedFisher(stub, "F001")
+	seedFisher(stub, "F002")
+	inactiveBytes, _ := json.Marshal(Fisher{ID: "F003", Name: "Fisher F003", Role: "fisher", Active: false})
+	stub.PutPrivateData("FisherCollection", "FISHER_F003", inactiveBytes)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 5, Date: "2025-08-09"})
+
+	sc := &SmartContract{}
+	if err := sc.ReassignCatch(ctx, "C001", "F002"); err != nil {
+		t.Fatalf("ReassignCatch failed: %v", err)
+	}
+
+	catch, err := sc.GetCatch(ctx, "C001")
+	if err != nil {
+		t.Fatalf("GetCatch failed: %v", err)
+	}
+	if catch.FisherID != "F002" || catch.ReassignedFrom != "F001" {
+		t.Errorf("expected catch moved from F001 to F002, got %+v", catch)
+	}
+	oldKey, _ := stub.CreateCompositeKey("fisher~catch", []string{"F001", "C001"})
+	newKey, _ := stub.CreateCompositeKey("fisher~catch", []string{"F002", "C001"})
+	if value, _ := stub.GetState(oldKey); value != nil {
+		t.Error("old fisher~catch entry should be removed")
+	}
+	if value, _ := stub.GetState(newKey); value == nil {
+		t.Error("new fisher~catch entry should be added")
+	}
+
+	oldCatches, _ := listFisherCatches(ctx, "F001", false)
+	if len(oldCatches) != 1 || oldCatches[0].CatchID != "C002" {
+		t.Errorf("expected F001 to keep only C002, got %v", oldCatches)
+	}
+	newCatches, _ := listFisherCatches(ctx, "F002", false)
+	if len(newCatches) != 1 || newCatches[0].CatchID != "C001" {
+		t.Errorf("expected F002 to have C001, got %v", newCatches)
+	}
+
+	tests := []struct {
+		name, catchId, fisherId string
+		check                   func(error) bool
+	}{
+		{"same fisher", "C001", "F002", func(err error) bool {
+			return err != nil && err.Error() == "catch C001 already belongs to fisher F002"
+		}},
+		{"unknown fisher", "C002", "F999", func(err error) bool { return isContractError(err, "NOT_FOUND", "fisher F999 does not exist") }},
+		{"inactive fisher", "C002", "F003", func(err error) bool { return err != nil && err.Error() == "fisher F003 is not active" }},
+		{"unknown catch", "C999", "F002", func(err error) bool { return isContractError(err, "NOT_FOUND", "catch C999 not found") }},
+	}
+	for _, tt := range tests {
+		if err := sc.ReassignCatch(ctx, tt.catchId, tt.fisherId); !tt.check(err) {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if err := sc.ReassignCatch(ctx, "C002", "F002"); !isContractError(err, "UNAUTHORIZED", "only authority can reassign catches") {
+		t.Errorf("ReassignCatch should fail for non-authority, got %v", err)
+	}
+}
+
+func TestGetActiveOrdersCount(t *testing.T) {
+	stub, ctx := setupStub(t)
+	sc := &SmartContract{}
+
+	count, err := sc.GetActiveOrdersCount(ctx)
+	if err != nil || count != 0 {
+		t.Errorf("expected 0 active orders on an empty ledger, got %d, %v", count, err)
+	}
+
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "delivered", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B001", BuyerID: "BUY1", Status: "cancelled", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+	count, err = sc.GetActiveOrdersCount(ctx)
+	if err != nil || count != 0 {
+		t.Errorf("expected 0 active orders when all are terminal, got %d, %v", count, err)
+	}
+
+	for _, order := range []Order{
+		{OrderID: "O003", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+		{OrderID: "O004", BatchID: "B001", BuyerID: "BUY2", Status: "confirmed", Date: "2025-08-10"},
+		{OrderID: "O005", BatchID: "B001", BuyerID: "BUY2", Status: "shipped", Date: "2025-08-10"},
+		{OrderID: "O006", BatchID: "B001", BuyerID: "BUY2", Status: "fulfilled", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+	count, err = sc.GetActiveOrdersCount(ctx)
+	if err != nil || count != 4 {
+		t.Errorf("expected 4 active orders, got %d, %v", count, err)
+	}
+}
+
+func TestFulfillFromBatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 40, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Tilapia", WeightKg: 30, Date: "2025-08-09"})
+	for _, batch := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-10"},
+		{BatchID: "B002", CatchIDs: []string{"C002"}, ProcessorID: "P001", Date: "2025-08-10"},
+		{BatchID: "B003", CatchIDs: []string{"C002"}, ProcessorID: "P001", Date: "2025-08-10", DeclaredWeightKg: 50},
+	} {
+		batchBytes, _ := json.Marshal(batch)
+		stub.PutState("BATCH_"+batch.BatchID, batchBytes)
+	}
+
+	sc := &SmartContract{}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if err := sc.PlaceOrder(ctx, "O001", "B001", "BUY001", "2025-08-11", "500", "USD", "100", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	order, _ := sc.GetOrder(ctx, "O001")
+	if order.Status != "placed" || order.OrderQuantityKg != 100 || order.FulfilledKg != 40 {
+		t.Errorf("expected placed order with 40 of 100 kg, got %+v", order)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+
+	// Partial fulfilment
+	if err := sc.FulfillFromBatch(ctx, "O001", "B002"); err != nil {
+		t.Fatalf("FulfillFromBatch B002 failed: %v", err)
+	}
+	order, _ = sc.GetOrder(ctx, "O001")
+	if order.Status != "placed" || order.FulfilledKg != 70 || !reflect.DeepEqual(order.BatchIDs, []string{"B001", "B002"}) {
+		t.Errorf("expected partially fulfilled order, got %+v", order)
+	}
+	if err := sc.FulfillFromBatch(ctx, "O001", "B002"); err == nil || err.Error() != "batch B002 already fulfils order O001" {
+		t.Errorf("expected duplicate batch error, got %v", err)
+	}
+
+	// Declared weight counts in preference to the catch total; only the outstanding 30 kg is claimed
+	if err := sc.FulfillFromBatch(ctx, "O001", "B003"); err != nil {
+		t.Fatalf("FulfillFromBatch B003 failed: %v", err)
+	}
+	order, _ = sc.GetOrder(ctx, "O001")
+	if order.Status != "fulfilled" || order.FulfilledKg != 100 || order.AllocatedKg["B003"] != 30 {
+		t.Errorf("expected fulfilled order with 100 kg and 30 kg from B003, got %+v", order)
+	}
+	if err := sc.FulfillFromBatch(ctx, "O001", "B004"); err == nil || err.Error() != "cannot fulfil order O001 with status fulfilled" {
+		t.Errorf("expected status error, got %v", err)
+	}
+	orders, _ := sc.GetOrdersByBatch(ctx, "B003", false)
+	if len(orders) != 1 || orders[0].OrderID != "O001" {
+		t.Errorf("expected O001 to be listed for B003, got %v", orders)
+	}
+
+	// A second order only gets the weight the first left unallocated
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if err := sc.PlaceOrder(ctx, "O003", "B003", "BUY002", "2025-08-11", "500", "USD", "40", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	order, _ = sc.GetOrder(ctx, "O003")
+	if order.Status != "placed" || order.FulfilledKg != 20 || order.AllocatedKg["B003"] != 20 {
+		t.Errorf("expected O003 to get the remaining 20 kg of B003, got %+v", order)
+	}
+	err := sc.PlaceOrder(ctx, "O004", "B001", "BUY002", "2025-08-11", "500", "USD", "10", "")
+	if err == nil || err.Error() != "batch B001 is fully allocated to other orders" {
+		t.Errorf("expected fully allocated error, got %v", err)
+	}
+
+	// Cancelling releases an order's allocation
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := sc.CancelOrder(ctx, "O003"); err != nil {
+		t.Fatalf("CancelOrder failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if err := sc.PlaceOrder(ctx, "O005", "B003", "BUY002", "2025-08-11", "500", "USD", "20", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	order, _ = sc.GetOrder(ctx, "O005")
+	if order.Status != "fulfilled" || order.AllocatedKg["B003"] != 20 {
+		t.Errorf("expected O005 to be fulfilled from B003, got %+v", order)
+	}
+
+	// Orders without a quantity can't take further batches
+	if err := sc.PlaceOrder(ctx, "O002", "B001", "BUY001", "2025-08-11", "500", "USD", "", ""); err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := sc.FulfillFromBatch(ctx, "O002", "B002"); err == nil || err.Error() != "order O002 has no quantity to fulfil" {
+		t.Errorf("expected missing quantity error, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "buyer")
+	if err := sc.FulfillFromBatch(ctx, "O002", "B002"); !isContractError(err, "UNAUTHORIZED", "only processor can fulfil orders") {
+		t.Errorf("FulfillFromBatch should fail for non-processor, got %v", err)
+	}
+}
+
+func TestMultiBatchOrder(t *testing.T) {
+	stub, ctx := setupStub(t)
+	sc := &SmartContract{}
+	seedFisher(stub, "F001")
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 40, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 30, Date: "2025-08-09"})
+	for _, batch := range []Batch{
+		{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-10"},
+		{BatchID: "B002", CatchIDs: []string{"C002"}, ProcessorID: "P001", Date: "2025-08-10"},
+	} {
+		batchBytes, _ := json.Marshal(batch)
+		stub.PutState("BATCH_"+batch.BatchID, batchBytes)
+	}
+	for _, quote := range []PriceQuote{{BatchID: "B001", ProcessorID: "P001", Price: 2}, {BatchID: "B002", ProcessorID: "P001", Price: 5}} {
+		quoteBytes, _ := json.Marshal(quote)
+		stub.PutPrivateData("PriceCollection", "PRICE_"+quote.BatchID, quoteBytes)
+	}
+	orderBytes, _ := json.Marshal(Order{OrderID: "O001", BatchID: "B001", BatchIDs: []string{"B001", "B002"}, BuyerID: "BUY001", Status: "confirmed", Date: "2025-08-11",
+		OrderQuantityKg: 50, FulfilledKg: 50, AllocatedKg: map[string]float64{"B001": 40, "B002": 10}})
+	stub.PutState("ORDER_O001", orderBytes)
+
+	// Trace covers every batch
+	result, err := sc.TraceOrder(ctx, "O001")
+	if err != nil {
+		t.Fatalf("TraceOrder failed: %v", err)
+	}
+	var trace OrderTrace
+	json.Unmarshal([]byte(result), &trace)
+	if len(trace.Batches) != 2 || len(trace.Catches) != 2 || len(trace.Missing) != 0 {
+		t.Errorf("expected both batches and catches in the trace, got %s", result)
+	}
+
+	// Validation checks every batch
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	report, err := sc.ValidateSupplyChain(ctx, "O001")
+	if err != nil {
+		t.Fatalf("ValidateSupplyChain failed: %v", err)
+	}
+	checked := map[string]bool{}
+	for _, check := range report.Checks {
+		checked[check.Name] = true
+	}
+	if !report.Valid || !checked["batch B001 exists"] || !checked["batch B002 exists"] {
+		t.Errorf("expected a valid report covering both batches, got %+v", report)
+	}
+
+	// Shipping marks every batch
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := sc.CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-12"); err != nil {
+		t.Fatalf("CreateShipment failed: %v", err)
+	}
+	for _, id := range []string{"B001", "B002"} {
+		batch, _ := sc.GetBatch(ctx, id)
+		if batch.ShippedAt == "" {
+			t.Errorf("batch %s should be marked shipped", id)
+		}
+	}
+
+	// Sales count only the allocated weight of each batch: (40*2 + 10*5) / 50 = 2.6
+	order, _ := sc.GetOrder(ctx, "O001")
+	order.Status = "delivered"
+	orderBytes, _ = json.Marshal(order)
+	stub.PutState("ORDER_O001", orderBytes)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	result, err = sc.GenerateSalesReport(ctx, "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GenerateSalesReport failed: %v", err)
+	}
+	var sales SalesReport
+	json.Unmarshal([]byte(result), &sales)
+	if sales.Orders != 1 || sales.TotalVolumeKg != 50 || sales.AveragePrice != 2.6 || sales.Skipped != 0 {
+		t.Errorf("unexpected sales report %+v", sales)
+	}
+}
+
+func TestGetAllFishers(t *testing.T) {
+	_, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	sc := &SmartContract{}
+
+	fishers, err := sc.GetAllFishers(ctx)
+	if err != nil || fishers == nil || len(fishers) != 0 {
+		t.Errorf("expected an empty fisher list, got %v, %v", fishers, err)
+	}
+
+	if err := sc.RegisterFisher(ctx, "F001", "John Doe", "GOV123"); err != nil {
+		t.Fatalf("RegisterFisher F001 failed: %v", err)
+	}
+	if err := sc.RegisterFisher(ctx, "F002", "Jane Doe", "GOV456"); err != nil {
+		t.Fatalf("RegisterFisher F002 failed: %v", err)
+	}
+
+	fishers, err = sc.GetAllFishers(ctx)
+	if err != nil {
+		t.Fatalf("GetAllFishers failed: %v", err)
+	}
+	if len(fishers) != 2 {
+		t.Fatalf("expected 2 fishers, got %d", len(fishers))
+	}
+	expected := map[string][2]string{"F001": {"John Doe", "GOV123"}, "F002": {"Jane Doe", "GOV456"}}
+	for _, fisher := range fishers {
+		want, ok := expected[fisher.ID]
+		if !ok || fisher.Name != want[0] || fisher.GovtID != want[1] || fisher.Role != "fisher" || !fisher.Active {
+			t.Errorf("unexpected fisher %+v", fisher)
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if _, err := sc.GetAllFishers(ctx); !isContractError(err, "UNAUTHORIZED", "only authority can list all fishers") {
+		t.Errorf("GetAllFishers should fail for non-authority, got %v", err)
+	}
+}
+
+func TestBatchHold(t *testing.T) {
+	stub, ctx := setupStub(t)
+	batchBytes, _ := json.Marshal(Batch{BatchID: "B001", CatchIDs: []string{"C001"}, ProcessorID: "P001", Date: "2025-08-09"})
+	stub.PutState("BATCH_B001", batchBytes)
+	for _, order := range []Order{
+		{OrderID: "O001", BatchID: "B001", BuyerID: "BUY1", Status: "confirmed", Date: "2025-08-10"},
+		{OrderID: "O002", BatchID: "B001", BuyerID: "BUY1", Status: "placed", Date: "2025-08-10"},
+	} {
+		orderBytes, _ := json.Marshal(order)
+		stub.PutState("ORDER_"+order.OrderID, orderBytes)
+	}
+
+	sc := &SmartContract{}
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := sc.HoldBatch(ctx, "B001", "histamine test"); !isContractError(err, "UNAUTHORIZED", "only authority can hold batches") {
+		t.Errorf("HoldBatch should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := sc.HoldBatch(ctx, "B001", ""); err == nil {
+		t.Error("HoldBatch should require a reason")
+	}
+	if err := sc.HoldBatch(ctx, "B001", "histamine test"); err != nil {
+		t.Fatalf("HoldBatch failed: %v", err)
+	}
+	batch, _ := sc.GetBatch(ctx, "B001")
+	if !batch.OnHold || batch.HoldReason != "histamine test" {
+		t.Errorf("expected batch on hold, got %+v", batch)
+	}
+
+	// Held batches can be neither shipped nor confirmed
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	err := sc.CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-11")
+	if err == nil || err.Error() != "batch B001 is on hold: histamine test" {
+		t.Errorf("CreateShipment should refuse a held batch, got %v", err)
+	}
+	err = sc.UpdateOrderStatus(ctx, "O002", "confirmed")
+	if err == nil || err.Error() != "batch B001 is on hold: histamine test" {
+		t.Errorf("confirming should refuse a held batch, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	if err := sc.ReleaseBatch(ctx, "B001"); err != nil {
+		t.Fatalf("ReleaseBatch failed: %v", err)
+	}
+	if err := sc.ReleaseBatch(ctx, "B001"); err == nil || err.Error() != "batch B001 is not on hold" {
+		t.Errorf("expected not on hold error, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	if err := sc.CreateShipment(ctx, "S001", "O001", "LakeFreight", "TRK-1", "2025-08-11"); err != nil {
+		t.Errorf("CreateShipment should succeed after release: %v", err)
+	}
+	if err := sc.UpdateOrderStatus(ctx, "O002", "confirmed"); err != nil {
+		t.Errorf("confirming should succeed after release: %v", err)
+	}
+}
+
+func TestGenerateReportByFisher(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", Species: "Tilapia", WeightKg: 10.5, Date: "2025-08-01"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F001", Species: "Nile Perch", WeightKg: 4.25, Date: "2025-08-15"})
+	seedCatch(stub, Catch{CatchID: "C003", FisherID: "F001", Species: "Tilapia", WeightKg: 7, Date: "2025-09-02"})
+	seedCatch(stub, Catch{CatchID: "C004", FisherID: "F002", Species: "Tilapia", WeightKg: 3, Date: "2025-08-31"})
+	seedCatch(stub, Catch{CatchID: "C005", FisherID: "F002", Species: "Tilapia", WeightKg: 9, Date: "2025-07-31"})
+	seedCatch(stub, Catch{CatchID: "C006", FisherID: "F003", Species: "Tilapia", WeightKg: 2, Date: "2025-07-01"})
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	_, err := (&SmartContract{}).GenerateReportByFisher(ctx, "2025-08-01", "2025-08-31")
+	if !isContractError(err, "UNAUTHORIZED", "only authority can generate reports") {
+		t.Errorf("GenerateReportByFisher should fail for non-authority, got %v", err)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	result, err := (&SmartContract{}).GenerateReportByFisher(ctx, "2025-08-01", "2025-08-31")
+	if err != nil {
+		t.Fatalf("GenerateReportByFisher failed: %v", err)
+	}
+	expected := `{"F001":{"count":2,"totalWeightKg":14.75},"F002":{"count":1,"totalWeightKg":3}}`
+	if result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func TestVerifyFisherSequence(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedSpecies(stub, "Tilapia")
+	seedLicense(stub, "F001")
+	seedFisher(stub, "F001")
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	ctx.GetClientIdentity().SetID("F001")
+
+	sc := &SmartContract{}
+	for _, catchId := range []string{"C001", "C002", "C003"} {
+		if err := sc.LogCatch(ctx, catchId, "F001", "Tilapia", "10", "2025-08-09", "-1.05", "33.10", "", "A", "2.5", "", ""); err != nil {
+			t.Fatalf("LogCatch %s failed: %v", catchId, err)
+		}
+	}
+	for i, catchId := range []string{"C001", "C002", "C003"} {
+		catch, _ := sc.GetCatch(ctx, catchId)
+		if catch.SeqNo != i+1 {
+			t.Errorf("expected %s to have SeqNo %d, got %d", catchId, i+1, catch.SeqNo)
+		}
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "authority")
+	check, err := sc.VerifyFisherSequence(ctx, "F001")
+	if err != nil {
+		t.Fatalf("VerifyFisherSequence failed: %v", err)
+	}
+	if !check.Complete || check.LastSeqNo != 3 || len(check.Missing) != 0 {
+		t.Errorf("expected a complete sequence, got %+v", check)
+	}
+
+	if err := sc.DeleteCatch(ctx, "C002"); err != nil {
+		t.Fatalf("DeleteCatch failed: %v", err)
+	}
+	check, err = sc.VerifyFisherSequence(ctx, "F001")
+	if err != nil {
+		t.Fatalf("VerifyFisherSequence failed: %v", err)
+	}
+	if check.Complete || len(check.Missing) != 1 || check.Missing[0] != 2 {
+		t.Errorf("expected SeqNo 2 to be missing, got %+v", check)
+	}
+
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "fisher")
+	if _, err := sc.VerifyFisherSequence(ctx, "F001"); !isContractError(err, "UNAUTHORIZED", "only authority can verify catch sequences") {
+		t.Errorf("VerifyFisherSequence should fail for non-authority, got %v", err)
+	}
+}
+
+func TestGetBatchesContainingCatch(t *testing.T) {
+	stub, ctx := setupStub(t)
+	ctx.GetClientIdentity().SetAttributeValue("hf.Role", "processor")
+	for _, catchId := range []string{"C001", "C002", "C003"} {
+		seedCatch(stub, Catch{CatchID: catchId, FisherID: "F001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-09"})
+	}
+
+	sc := &SmartContract{}
+	batches, err := sc.GetBatchesContainingCatch(ctx, "C001")
+	if err != nil || batches == nil || len(batches) != 0 {
+		t.Errorf("expected no batches for an unbatched catch, got %v, %v", batches, err)
+	}
+
+	for _, b := range []struct {
+		batchId  string
+		catchIds []string
+	}{
+		{"B001", []string{"C001"}},
+		{"B002", []string{"C002"}},
+		{"B003", []string{"C001", "C003"}},
+	} {
+		if err := sc.CreateBatch(ctx, b.batchId, b.catchIds, "P001", "2025-08-10", "", "", ""); err != nil {
+			t.Fatalf("CreateBatch %s failed: %v", b.batchId, err)
+		}
+	}
+	if err := sc.MergeBatches(ctx, "B002", []string{"B003"}); err != nil {
+		t.Fatalf("MergeBatches failed: %v", err)
+	}
+
+	batches, err = sc.GetBatchesContainingCatch(ctx, "C001")
+	if err != nil {
+		t.Fatalf("GetBatchesContainingCatch failed: %v", err)
+	}
+	found := map[string]bool{}
+	for _, batch := range batches {
+		found[batch.BatchID] = true
+	}
+	if len(batches) != 2 || !found["B001"] || !found["B002"] {
+		t.Errorf("expected C001 in B001 and B002, got %v", batches)
+	}
+}
+
+func TestGetCatchProvenance(t *testing.T) {
+	stub, ctx := setupStub(t)
+	seedFisher(stub, "F001")
+	vesselBytes, _ := json.Marshal(Vessel{ID: "V001", Name: "Lake Star", RegistrationNo: "UG-123", OwnerFisherID: "F001"})
+	stub.PutState("VESSEL_V001", vesselBytes)
+	seedCatch(stub, Catch{CatchID: "C001", FisherID: "F001", VesselID: "V001", Species: "Tilapia", WeightKg: 10, Date: "2025-08-09"})
+	seedCatch(stub, Catch{CatchID: "C002", FisherID: "F404", Species: "Tilapia", WeightKg: 5, Date: "2025-08-09"})
+
+	result, err := (&SmartContract{}).GetCatchProvenance(ctx, "C001")
+	if err != nil {
+		t.Fatalf("GetCatchProvenance failed: %v", err)
+	}
+	if !strings.Contains(result, `"name":"Fisher F001"`) || !strings.Contains(result, `"registrationNo":"UG-123"`) {
+		t.Errorf("expected fisher name and vessel in provenance, got %s", result)
+	}
+	if strings.Contains(result, "govtId") || strings.Contains(result, "GOV_F001") {
+		t.Errorf("provenance must not include GovtID, got %s", result)
+	}
+
+	// A missing fisher is reported rather than failing the scan
+	result, err = (&SmartContract{}).GetCatchProvenance(ctx, "C002")
+	if err != nil {
+		t.Fatalf("GetCatchProvenance failed: %v", err)
+	}
+	var provenance CatchProvenance
+	json.Unmarshal([]byte(result), &provenance)
+	if provenance.Fisher != nil || len(provenance.Missing) != 1 || provenance.Missing[0] != "NOT_FOUND: fisher F404 does not exist" {
+		t.Errorf("expected missing fisher F404, got %s", result)
+	}
+	if provenance.Vessel != nil {
+		t.Errorf("expected no vessel for C002, got %+v", provenance.Vessel)
+	}
+
+	_, err = (&SmartContract{}).GetCatchProvenance(ctx, "C999")
+	if !isContractError(err, "NOT_FOUND", "catch C999 not found") {
+		t.Errorf("expected catch not found, got %v", err)
+	}
+}